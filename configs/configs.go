@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"go.uber.org/multierr"
@@ -17,11 +18,12 @@ type Config struct {
 		LogLevel string `json:"log_level" yaml:"log_level"`
 	} `json:"exporter" yaml:"exporter"`
 	Harbor struct {
-		Address   string `json:"address" yaml:"address"`
-		Enabled   bool   `json:"enabled" yaml:"enabled"`
-		Token     string `json:"token"   yaml:"token"`
-		TokenPath string `json:"token_path" yaml:"token_path"`
-		UseTLS    bool   `json:"use_tls" yaml:"use_tls"`
+		Address   string                     `json:"address" yaml:"address"`
+		Enabled   bool                       `json:"enabled" yaml:"enabled"`
+		Token     string                     `json:"token"   yaml:"token"`
+		TokenPath string                     `json:"token_path" yaml:"token_path"`
+		UseTLS    bool                       `json:"use_tls" yaml:"use_tls"`
+		Instances []exporters.HarborInstance `json:"instances" yaml:"instances"`
 	} `json:"harbor" yaml:"harbor"`
 	Keystone struct {
 		Enabled bool              `json:"enabled" yaml:"enabled"`
@@ -34,6 +36,11 @@ type Config struct {
 		TokenPath     string   `json:"token_path" yaml:"token_path"`
 		UseTLS        bool     `json:"use_tls" yaml:"use_tls"`
 		IgnoreTenants []string `json:"ignore_tenants" yaml:"ignore_tenants"`
+		Backend       string   `json:"backend" yaml:"backend"`
+		// FullResyncInterval is how often the whole snapshot is refetched,
+		// the runs in between only apply the NetBox changelog.
+		FullResyncInterval time.Duration               `json:"full_resync_interval" yaml:"full_resync_interval"`
+		Concurrency        exporters.NetboxConcurrency `json:"concurrency" yaml:"concurrency"`
 	} `json:"netbox" yaml:"netbox"`
 }
 
@@ -44,7 +51,11 @@ func Load(filePath string) (*Config, error) {
 			return nil, multierr.Combine(err, envErr)
 		}
 	}
-	cfg.setHarborTokenFromFile()
+	cfg.setHarborInstances()
+	if err := cfg.setHarborInstanceDefaults(); err != nil {
+		return nil, err
+	}
+	cfg.setHarborSecretsFromFile()
 	cfg.setNetboxTokenFromFile()
 
 	return cfg, nil
@@ -63,28 +74,84 @@ func Default() *Config {
 
 	cfg.Netbox.Enabled = true
 	cfg.Netbox.UseTLS = true
+	cfg.Netbox.Backend = exporters.NetboxBackendREST
+	cfg.Netbox.FullResyncInterval = time.Hour
+	cfg.Netbox.Concurrency = exporters.NetboxConcurrency{
+		Workers:           8,
+		MaxPerHost:        8,
+		RequestsPerSecond: 20,
+	}
 
 	return cfg
 }
 
-func (c *Config) setHarborTokenFromFile() {
-	if !c.Harbor.Enabled {
+// setHarborInstances turns the top level harbor address into a single
+// instance when no instances list is configured.
+func (c *Config) setHarborInstances() {
+	if !c.Harbor.Enabled || len(c.Harbor.Instances) > 0 || c.Harbor.Address == "" {
 		return
 	}
 
-	if c.Harbor.Token != "" {
+	c.Harbor.Instances = []exporters.HarborInstance{{
+		Address:   c.Harbor.Address,
+		Token:     c.Harbor.Token,
+		TokenPath: c.Harbor.TokenPath,
+		UseTLS:    &c.Harbor.UseTLS,
+	}}
+}
+
+// setHarborInstanceDefaults names unnamed instances after their address and
+// turns TLS on for instances that leave use_tls out. Names must be unique, as
+// they tell the metrics of the instances apart.
+func (c *Config) setHarborInstanceDefaults() error {
+	if !c.Harbor.Enabled {
+		return nil
+	}
+
+	names := make(map[string]bool, len(c.Harbor.Instances))
+	for i := range c.Harbor.Instances {
+		instance := &c.Harbor.Instances[i]
+		if instance.Name == "" {
+			instance.Name = instance.Address
+		}
+		if names[instance.Name] {
+			return fmt.Errorf("harbor instance name %q is used more than once, give every instance a unique name", instance.Name)
+		}
+		names[instance.Name] = true
+
+		if instance.UseTLS == nil {
+			useTLS := true
+			instance.UseTLS = &useTLS
+		}
+	}
+	return nil
+}
+
+func (c *Config) setHarborSecretsFromFile() {
+	if !c.Harbor.Enabled {
 		return
 	}
 
-	if c.Harbor.TokenPath != "" {
-		data, err := os.ReadFile(c.Harbor.TokenPath)
-		if err != nil {
-			fmt.Println("could not read file", c.Harbor.TokenPath, "Error:", err)
-			os.Exit(4)
+	for i := range c.Harbor.Instances {
+		instance := &c.Harbor.Instances[i]
+		if instance.Token == "" && instance.TokenPath != "" {
+			instance.Token = readSecretFile(instance.TokenPath)
+		}
+		if instance.Auth.Secret == "" && instance.Auth.SecretPath != "" {
+			instance.Auth.Secret = readSecretFile(instance.Auth.SecretPath)
 		}
-		c.Harbor.Token = strings.TrimSpace(string(data))
 	}
 }
+
+func readSecretFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("could not read file", path, "Error:", err)
+		os.Exit(4)
+	}
+	return strings.TrimSpace(string(data))
+}
+
 func (c *Config) setNetboxTokenFromFile() {
 	if !c.Netbox.Enabled {
 		return
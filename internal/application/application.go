@@ -76,14 +76,38 @@ func (a *Application) registerRoutes() {
 
 func (a *Application) registerExporters() {
 	if a.Config.Harbor.Enabled {
-		a.Logger.Debug("Registering Harbor")
-		prometheus.MustRegister(exporters.NewHarborCollector(a.Config.Harbor.Address, a.Config.Harbor.Token, a.Config.Harbor.UseTLS))
+		for _, instance := range a.Config.Harbor.Instances {
+			a.Logger.Debug("Registering Harbor ", instance.Name)
+			collector, err := exporters.NewHarborCollector(instance)
+			if err != nil {
+				a.Logger.WithError(err).Fatal("could not create harbor collector")
+			}
+			prometheus.MustRegister(collector)
+		}
 	}
 
 	if a.Config.Keystone.Enabled {
 		for _, cloud := range a.Config.Keystone.Clouds {
 			a.Logger.Debug("Registering ", cloud.OpenstackName)
 			prometheus.MustRegister(exporters.NewKeystoneCollector(cloud))
+			if cloud.Quotas {
+				prometheus.MustRegister(exporters.NewQuotaCollector(cloud))
+			}
+			if cloud.Hypervisors {
+				prometheus.MustRegister(exporters.NewHypervisorCollector(cloud))
+			}
+			if cloud.Endpoints {
+				prometheus.MustRegister(exporters.NewEndpointCollector(cloud))
+			}
+			if cloud.Instances {
+				prometheus.MustRegister(exporters.NewInstanceCollector(cloud))
+			}
+			if cloud.LoadBalancers {
+				prometheus.MustRegister(exporters.NewLoadBalancerCollector(cloud))
+			}
+			if cloud.Network {
+				prometheus.MustRegister(exporters.NewNetworkCollector(cloud))
+			}
 		}
 	}
 	if a.Config.Netbox.Enabled {
@@ -98,6 +122,9 @@ func (a *Application) registerExporters() {
 			netboxToken,
 			a.Config.Netbox.UseTLS,
 			a.Config.Netbox.IgnoreTenants,
+			a.Config.Netbox.Backend,
+			a.Config.Netbox.Concurrency,
+			a.Config.Netbox.FullResyncInterval,
 		)
 
 		prometheus.MustRegister(
@@ -1,20 +1,30 @@
 package exporters
 
 import (
-	"encoding/json"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const (
-	namespace = "custom_harbor"
-	subsystem = "registries"
+	namespace           = "custom_harbor"
+	subsystem           = "registries"
+	projectSubsystem    = "project"
+	repoSubsystem       = "repository"
+	scannerSubsystem    = "scanner"
+	replSubsystem       = "replication"
+	gcSubsystem         = "gc"
+	retentionSubsystem  = "retention"
+	jobserviceSubsystem = "jobservice"
+	robotSubsystem      = "robot"
 )
 
 type registryEntry struct {
@@ -22,79 +32,338 @@ type registryEntry struct {
 	Status string `json:"status"`
 }
 
+// HarborAuth holds the credentials the exporter builds the Authorization
+// header from. Mode is one of basic (username and password), robot (robot
+// account name and secret) or oidc (username and OIDC CLI secret).
+type HarborAuth struct {
+	Mode       string `json:"mode" yaml:"mode"`
+	Username   string `json:"username" yaml:"username"`
+	Secret     string `json:"secret" yaml:"secret"`
+	SecretPath string `json:"secret_path" yaml:"secret_path"`
+}
+
+// HarborInstance describes a single Harbor to scrape. Every metric of the
+// instance carries its Name in the harbor_instance label, instance is left to
+// the Prometheus target. Token is a pre-encoded
+// Basic credential, used only when Auth.Mode is empty. UseTLS defaults to true
+// when left out.
+type HarborInstance struct {
+	Name               string                `json:"name" yaml:"name"`
+	Address            string                `json:"address" yaml:"address"`
+	Token              string                `json:"token" yaml:"token"`
+	TokenPath          string                `json:"token_path" yaml:"token_path"`
+	Auth               HarborAuth            `json:"auth" yaml:"auth"`
+	Repositories       HarborRepositories    `json:"repositories" yaml:"repositories"`
+	Vulnerabilities    HarborVulnerabilities `json:"vulnerabilities" yaml:"vulnerabilities"`
+	UseTLS             *bool                 `json:"use_tls" yaml:"use_tls"`
+	InsecureSkipVerify bool                  `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	CAPath             string                `json:"ca_path" yaml:"ca_path"`
+}
+
+func (i HarborInstance) authorization() (string, error) {
+	switch i.Auth.Mode {
+	case "":
+		if i.Token == "" {
+			return "", nil
+		}
+		return "Basic " + i.Token, nil
+	case "basic", "robot", "oidc":
+		if i.Auth.Username == "" || i.Auth.Secret == "" {
+			return "", fmt.Errorf("harbor auth mode %s requires both username and secret", i.Auth.Mode)
+		}
+		credentials := i.Auth.Username + ":" + i.Auth.Secret
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)), nil
+	default:
+		return "", fmt.Errorf("unknown harbor auth mode %q", i.Auth.Mode)
+	}
+}
+
 type HarborCollector struct {
-	HarborAddress                 string
-	RegistriesBackendHealthStatus *prometheus.Desc
-	Token                         string
-	UseTLS                        bool
+	Instance                         string
+	HarborAddress                    string
+	RegistriesBackendHealthStatus    *prometheus.Desc
+	RegistriesFound                  *prometheus.Desc
+	RegistriesReported               *prometheus.Desc
+	ProjectInfo                      *prometheus.Desc
+	ProjectRepositories              *prometheus.Desc
+	ProjectStorageUsed               *prometheus.Desc
+	ProjectStorageQuota              *prometheus.Desc
+	ProjectVulnerabilities           *prometheus.Desc
+	RepositoryVulnerabilities        *prometheus.Desc
+	RepositoryScanAge                *prometheus.Desc
+	ScannerHealth                    *prometheus.Desc
+	ReplicationPolicyInfo            *prometheus.Desc
+	ReplicationLastExecutionStatus   *prometheus.Desc
+	ReplicationLastExecutionDuration *prometheus.Desc
+	ReplicationLastExecutionTasks    *prometheus.Desc
+	ReplicationSinceLastSuccess      *prometheus.Desc
+	GCLastRunStatus                  *prometheus.Desc
+	GCLastRunStart                   *prometheus.Desc
+	GCLastRunEnd                     *prometheus.Desc
+	GCLastRunFreedBytes              *prometheus.Desc
+	GCLastRunDeletedArtifacts        *prometheus.Desc
+	RetentionLastRunStatus           *prometheus.Desc
+	RetentionLastRunStart            *prometheus.Desc
+	RetentionLastRunEnd              *prometheus.Desc
+	RetentionLastRunDeletedArtifacts *prometheus.Desc
+	HealthStatus                     *prometheus.Desc
+	ComponentHealthStatus            *prometheus.Desc
+	JobQueueDepth                    *prometheus.Desc
+	JobQueueLatency                  *prometheus.Desc
+	JobQueuePaused                   *prometheus.Desc
+	RobotExpiry                      *prometheus.Desc
+	RobotDaysRemaining               *prometheus.Desc
+	RobotDisabled                    *prometheus.Desc
+	CollectorSuccess                 *prometheus.Desc
+	AuthFailed                       *prometheus.Desc
+	AuthFailuresTotal                *prometheus.Desc
+	RepositoryArtifacts              *prometheus.Desc
+	RepositorySize                   *prometheus.Desc
+	RepositoryPulls                  *prometheus.Desc
+	RepositoryLastPush               *prometheus.Desc
+	RepositoryInventoryDropped       *prometheus.Desc
+	UseTLS                           bool
+
+	authorization   string
+	repositories    HarborRepositories
+	vulnerabilities HarborVulnerabilities
+	authFailures    atomic.Uint64
+	httpClient      *http.Client
 }
 
-func NewHarborCollector(harborAddr, token string, useTLS bool) *HarborCollector {
-	healthDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "health"), "fetch harbor registry backend health status", []string{"registry"}, nil)
-	return &HarborCollector{
-		HarborAddress:                 harborAddr,
-		RegistriesBackendHealthStatus: healthDesc,
-		Token:                         token,
-		UseTLS:                        useTLS,
+func NewHarborCollector(instance HarborInstance) (*HarborCollector, error) {
+	authorization, err := instance.authorization()
+	if err != nil {
+		return nil, err
+	}
+
+	useTLS := instance.UseTLS == nil || *instance.UseTLS
+	if !useTLS && authorization != "" {
+		fmt.Printf("harbor %s: use_tls is disabled, credentials are sent over plain HTTP\n", instance.Name)
 	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: instance.InsecureSkipVerify}
+	if instance.CAPath != "" {
+		ca, err := os.ReadFile(instance.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read harbor CA file %s: %w", instance.CAPath, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in harbor CA file %s", instance.CAPath)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	constLabels := prometheus.Labels{"harbor_instance": instance.Name}
+	healthDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "health"), "fetch harbor registry backend health status", []string{"registry"}, constLabels)
+	foundDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "found"), "number of registries fetched from harbor across all pages", nil, constLabels)
+	reportedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, "reported"), "number of registries reported by harbor in the X-Total-Count header", nil, constLabels)
+	projectInfoDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, projectSubsystem, "info"), "harbor project metadata", []string{"project", "public", "owner"}, constLabels)
+	projectReposDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, projectSubsystem, "repositories"), "number of repositories in a harbor project", []string{"project"}, constLabels)
+	projectUsedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, projectSubsystem, "storage_used_bytes"), "storage used by a harbor project in bytes", []string{"project"}, constLabels)
+	projectQuotaDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, projectSubsystem, "storage_quota_bytes"), "hard storage quota of a harbor project in bytes, -1 means unlimited", []string{"project"}, constLabels)
+	projectVulnDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, projectSubsystem, "vulnerabilities"), "number of vulnerabilities by severity in the newest artifact of each repository of a harbor project, when scanned", []string{"project", "severity"}, constLabels)
+	repoVulnDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "vulnerabilities"), "number of vulnerabilities by severity in the newest artifact of a harbor repository, when scanned", []string{"project", "repository", "severity"}, constLabels)
+	repoScanAgeDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "scan_age_seconds"), "seconds since the newest artifact of a harbor repository was scanned", []string{"project", "repository"}, constLabels)
+	scannerHealthDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, scannerSubsystem, "health"), "fetch harbor scanner health status", []string{"scanner", "is_default", "disabled"}, constLabels)
+	replPolicyDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, replSubsystem, "policy_info"), "harbor replication policy metadata", []string{"policy", "enabled", "trigger", "source", "destination"}, constLabels)
+	replStatusDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, replSubsystem, "last_execution_status"), "status of the latest execution of a harbor replication policy", []string{"policy", "status"}, constLabels)
+	replDurationDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, replSubsystem, "last_execution_duration_seconds"), "duration of the latest execution of a harbor replication policy, up to now when still running", []string{"policy"}, constLabels)
+	replTasksDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, replSubsystem, "last_execution_tasks"), "number of tasks by state in the latest execution of a harbor replication policy", []string{"policy", "state"}, constLabels)
+	replSinceSuccessDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, replSubsystem, "seconds_since_last_success"), "seconds since the last successful execution of a harbor replication policy finished", []string{"policy"}, constLabels)
+	gcStatusDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, gcSubsystem, "last_run_status"), "status of the latest harbor garbage collection run", []string{"status"}, constLabels)
+	gcStartDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, gcSubsystem, "last_run_start_timestamp_seconds"), "start time of the latest harbor garbage collection run", nil, constLabels)
+	gcEndDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, gcSubsystem, "last_run_end_timestamp_seconds"), "end time of the latest finished harbor garbage collection run", nil, constLabels)
+	gcFreedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, gcSubsystem, "last_run_freed_bytes"), "bytes freed by the latest harbor garbage collection run, as reported in its log", nil, constLabels)
+	gcDeletedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, gcSubsystem, "last_run_deleted_artifacts"), "number of manifests deleted by the latest harbor garbage collection run, as reported in its log", nil, constLabels)
+	retentionStatusDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, retentionSubsystem, "last_run_status"), "status of the latest tag retention execution of a harbor project", []string{"project", "status"}, constLabels)
+	retentionStartDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, retentionSubsystem, "last_run_start_timestamp_seconds"), "start time of the latest tag retention execution of a harbor project", []string{"project"}, constLabels)
+	retentionEndDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, retentionSubsystem, "last_run_end_timestamp_seconds"), "end time of the latest finished tag retention execution of a harbor project", []string{"project"}, constLabels)
+	retentionDeletedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, retentionSubsystem, "last_run_deleted_artifacts"), "number of artifacts deleted by the latest tag retention execution of a harbor project", []string{"project"}, constLabels)
+	healthStatusDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "health"), "fetch overall harbor health status", nil, constLabels)
+	componentHealthDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "component", "health"), "fetch harbor component health status", []string{"component"}, constLabels)
+	queueDepthDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, jobserviceSubsystem, "queue_depth"), "number of pending jobs in a harbor job service queue", []string{"job_type"}, constLabels)
+	queueLatencyDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, jobserviceSubsystem, "queue_latency_seconds"), "age of the oldest pending job in a harbor job service queue", []string{"job_type"}, constLabels)
+	queuePausedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, jobserviceSubsystem, "queue_paused"), "whether a harbor job service queue is paused", []string{"job_type"}, constLabels)
+	robotExpiryDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, robotSubsystem, "expiry_timestamp_seconds"), "expiry time of a harbor robot account, absent for robots that never expire", []string{"robot", "level", "project"}, constLabels)
+	robotDaysDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, robotSubsystem, "days_remaining"), "days until a harbor robot account expires, negative once expired", []string{"robot", "level", "project"}, constLabels)
+	robotDisabledDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, robotSubsystem, "disabled"), "whether a harbor robot account is disabled", []string{"robot", "level", "project"}, constLabels)
+	collectorSuccessDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "collector", "success"), "whether the last scrape of a harbor collector succeeded", []string{"collector"}, constLabels)
+	authFailedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "auth", "failed"), "whether harbor rejected the configured credentials during the last scrape", nil, constLabels)
+	authFailuresDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, "auth", "failures_total"), "number of scrapes in which harbor rejected the configured credentials", nil, constLabels)
+	repoArtifactsDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "artifacts"), "number of artifacts in a harbor repository", []string{"project", "repository"}, constLabels)
+	repoSizeDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "size_bytes"), "total size of the artifacts in a harbor repository", []string{"project", "repository"}, constLabels)
+	repoPullsDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "pulls_total"), "number of pulls of a harbor repository", []string{"project", "repository"}, constLabels)
+	repoLastPushDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "last_push_timestamp_seconds"), "push time of the most recently pushed artifact of a harbor repository", []string{"project", "repository"}, constLabels)
+	repoDroppedDesc := prometheus.NewDesc(prometheus.BuildFQName(namespace, repoSubsystem, "inventory_dropped"), "number of harbor repositories left out of the inventory metrics by the top_n limit", nil, constLabels)
+	return &HarborCollector{
+		Instance:                         instance.Name,
+		HarborAddress:                    instance.Address,
+		RegistriesBackendHealthStatus:    healthDesc,
+		RegistriesFound:                  foundDesc,
+		RegistriesReported:               reportedDesc,
+		ProjectInfo:                      projectInfoDesc,
+		ProjectRepositories:              projectReposDesc,
+		ProjectStorageUsed:               projectUsedDesc,
+		ProjectStorageQuota:              projectQuotaDesc,
+		ProjectVulnerabilities:           projectVulnDesc,
+		RepositoryVulnerabilities:        repoVulnDesc,
+		RepositoryScanAge:                repoScanAgeDesc,
+		ScannerHealth:                    scannerHealthDesc,
+		ReplicationPolicyInfo:            replPolicyDesc,
+		ReplicationLastExecutionStatus:   replStatusDesc,
+		ReplicationLastExecutionDuration: replDurationDesc,
+		ReplicationLastExecutionTasks:    replTasksDesc,
+		ReplicationSinceLastSuccess:      replSinceSuccessDesc,
+		GCLastRunStatus:                  gcStatusDesc,
+		GCLastRunStart:                   gcStartDesc,
+		GCLastRunEnd:                     gcEndDesc,
+		GCLastRunFreedBytes:              gcFreedDesc,
+		GCLastRunDeletedArtifacts:        gcDeletedDesc,
+		RetentionLastRunStatus:           retentionStatusDesc,
+		RetentionLastRunStart:            retentionStartDesc,
+		RetentionLastRunEnd:              retentionEndDesc,
+		RetentionLastRunDeletedArtifacts: retentionDeletedDesc,
+		HealthStatus:                     healthStatusDesc,
+		ComponentHealthStatus:            componentHealthDesc,
+		JobQueueDepth:                    queueDepthDesc,
+		JobQueueLatency:                  queueLatencyDesc,
+		JobQueuePaused:                   queuePausedDesc,
+		RobotExpiry:                      robotExpiryDesc,
+		RobotDaysRemaining:               robotDaysDesc,
+		RobotDisabled:                    robotDisabledDesc,
+		CollectorSuccess:                 collectorSuccessDesc,
+		AuthFailed:                       authFailedDesc,
+		AuthFailuresTotal:                authFailuresDesc,
+		UseTLS:                           useTLS,
+		RepositoryArtifacts:              repoArtifactsDesc,
+		RepositorySize:                   repoSizeDesc,
+		RepositoryPulls:                  repoPullsDesc,
+		RepositoryLastPush:               repoLastPushDesc,
+		RepositoryInventoryDropped:       repoDroppedDesc,
+		authorization:                    authorization,
+		repositories:                     instance.Repositories,
+		vulnerabilities:                  instance.Vulnerabilities,
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		},
+	}, nil
 }
 
 func (h *HarborCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- h.RegistriesBackendHealthStatus
+	ch <- h.RegistriesFound
+	ch <- h.RegistriesReported
+	ch <- h.ProjectInfo
+	ch <- h.ProjectRepositories
+	ch <- h.ProjectStorageUsed
+	ch <- h.ProjectStorageQuota
+	ch <- h.ProjectVulnerabilities
+	ch <- h.RepositoryVulnerabilities
+	ch <- h.RepositoryScanAge
+	ch <- h.ScannerHealth
+	ch <- h.ReplicationPolicyInfo
+	ch <- h.ReplicationLastExecutionStatus
+	ch <- h.ReplicationLastExecutionDuration
+	ch <- h.ReplicationLastExecutionTasks
+	ch <- h.ReplicationSinceLastSuccess
+	ch <- h.GCLastRunStatus
+	ch <- h.GCLastRunStart
+	ch <- h.GCLastRunEnd
+	ch <- h.GCLastRunFreedBytes
+	ch <- h.GCLastRunDeletedArtifacts
+	ch <- h.RetentionLastRunStatus
+	ch <- h.RetentionLastRunStart
+	ch <- h.RetentionLastRunEnd
+	ch <- h.RetentionLastRunDeletedArtifacts
+	ch <- h.HealthStatus
+	ch <- h.ComponentHealthStatus
+	ch <- h.JobQueueDepth
+	ch <- h.JobQueueLatency
+	ch <- h.JobQueuePaused
+	ch <- h.RobotExpiry
+	ch <- h.RobotDaysRemaining
+	ch <- h.RobotDisabled
+	ch <- h.CollectorSuccess
+	ch <- h.AuthFailed
+	ch <- h.AuthFailuresTotal
+	ch <- h.RepositoryArtifacts
+	ch <- h.RepositorySize
+	ch <- h.RepositoryPulls
+	ch <- h.RepositoryLastPush
+	ch <- h.RepositoryInventoryDropped
 }
 
 func (h *HarborCollector) Collect(ch chan<- prometheus.Metric) {
-	h.collectHarborRegistryBackendHealthStatus(ch)
-}
-
-func (h *HarborCollector) collectHarborRegistryBackendHealthStatus(ch chan<- prometheus.Metric) {
-	var schema string
-	if h.UseTLS {
-		schema = "https://"
-	} else {
-		schema = "http://"
+	type harborSubCollector struct {
+		name    string
+		collect func(chan<- prometheus.Metric) error
 	}
-	url := schema + h.HarborAddress + "/api/v2.0/registries?page=1&page_size=100"
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		fmt.Printf("client: could not create request: %s\n", err)
-		os.Exit(1)
+	// Sub-collectors that need the projects or repositories share the lists
+	// of this scrape.
+	s := newHarborScrape(h)
+	withScrape := func(collect func(*harborScrape, chan<- prometheus.Metric) error) func(chan<- prometheus.Metric) error {
+		return func(ch chan<- prometheus.Metric) error { return collect(s, ch) }
 	}
-	req.Header.Set("access", "application/json")
-	req.Header.Set("Authorization", "Basic "+h.Token)
-
-	client := http.Client{
-		Timeout: 30 * time.Second,
+	collectors := []harborSubCollector{
+		{"registries", h.collectHarborRegistryBackendHealthStatus},
+		{"projects", withScrape(h.collectProjects)},
+		{"scanners", h.collectScanners},
+		{"replication", h.collectReplication},
+		{"gc", h.collectGarbageCollection},
+		{"retention", withScrape(h.collectRetention)},
+		{"health", h.collectHealth},
+		{"jobservice queues", h.collectJobQueues},
+		{"robots", h.collectRobots},
+	}
+	if h.repositories.Enabled {
+		collectors = append(collectors, harborSubCollector{"repositories", withScrape(h.collectRepositories)})
+	}
+	if h.vulnerabilities.Enabled {
+		collectors = append(collectors, harborSubCollector{"vulnerabilities", withScrape(h.collectVulnerabilities)})
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("client: error making http request: %s\n", err)
-		return
+	// Once harbor rejects the credentials the remaining collectors, in the
+	// order above, are skipped instead of repeating the failing requests.
+	authFailed := false
+	for _, c := range collectors {
+		success := 0.0
+		if !authFailed {
+			if err := c.collect(ch); err != nil {
+				fmt.Printf("harbor %s: could not collect %s: %s\n", h.Instance, c.name, err)
+				authFailed = isHarborAuthError(err)
+			} else {
+				success = 1
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(h.CollectorSuccess, prometheus.GaugeValue, success, c.name)
 	}
 
-	responseBody, errRead := io.ReadAll(resp.Body)
-	if errRead != nil {
-		fmt.Println("Error happened", errRead)
-		return
+	authFailedValue := 0.0
+	if authFailed {
+		authFailedValue = 1
+		h.authFailures.Add(1)
 	}
+	ch <- prometheus.MustNewConstMetric(h.AuthFailed, prometheus.GaugeValue, authFailedValue)
+	ch <- prometheus.MustNewConstMetric(h.AuthFailuresTotal, prometheus.CounterValue, float64(h.authFailures.Load()))
+}
 
-	defer func(b io.ReadCloser) {
-		if b == nil {
-			return
-		}
-		err = errors.Join(err, b.Close())
-	}(resp.Body)
+func isHarborAuthError(err error) bool {
+	var httpErr *harborHTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized
+}
 
-	var endpointData []registryEntry
-	err = json.Unmarshal(responseBody, &endpointData)
+func (h *HarborCollector) collectHarborRegistryBackendHealthStatus(ch chan<- prometheus.Metric) error {
+	registries, reported, err := harborListAll[registryEntry](h, "/api/v2.0/registries")
 	if err != nil {
-		fmt.Println("Error happened while Unmarshalling data:", err, ". response body:", string(responseBody))
-		return
+		return err
 	}
 
 	var value float64
-	for _, entry := range endpointData {
+	for _, entry := range registries {
 		if entry.Status == "healthy" {
 			value = 1
 		} else {
@@ -102,4 +371,10 @@ func (h *HarborCollector) collectHarborRegistryBackendHealthStatus(ch chan<- pro
 		}
 		ch <- prometheus.MustNewConstMetric(h.RegistriesBackendHealthStatus, prometheus.GaugeValue, value, entry.Name)
 	}
+
+	ch <- prometheus.MustNewConstMetric(h.RegistriesFound, prometheus.GaugeValue, float64(len(registries)))
+	if reported >= 0 {
+		ch <- prometheus.MustNewConstMetric(h.RegistriesReported, prometheus.GaugeValue, float64(reported))
+	}
+	return nil
 }
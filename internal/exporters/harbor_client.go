@@ -0,0 +1,148 @@
+package exporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const harborPageSize = 100
+
+type harborHTTPError struct {
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *harborHTTPError) Error() string {
+	return fmt.Sprintf("harbor http %d from %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+func (h *HarborCollector) baseURL() string {
+	if h.UseTLS {
+		return "https://" + h.HarborAddress
+	}
+	return "http://" + h.HarborAddress
+}
+
+func (h *HarborCollector) getJSON(path string, dst interface{}) (http.Header, error) {
+	body, header, err := h.get(path, "application/json")
+	if err != nil {
+		return header, err
+	}
+
+	if err := json.Unmarshal(body, dst); err != nil {
+		return header, fmt.Errorf("could not unmarshal response from %s: %w. response body: %s", path, err, string(body))
+	}
+	return header, nil
+}
+
+func (h *HarborCollector) get(path, accept string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, h.baseURL()+path, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	if h.authorization != "" {
+		req.Header.Set("Authorization", h.authorization)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error making http request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, fmt.Errorf("could not read response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.Header, &harborHTTPError{Path: path, StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return body, resp.Header, nil
+}
+
+// harborListAll fetches every page of a Harbor list endpoint by following the
+// rel="next" entry of the Link header. The second return value is the total
+// reported by Harbor in X-Total-Count, or -1 when the header is missing. A
+// Link pointing back to a page already fetched ends the list.
+func harborListAll[T any](h *HarborCollector, path string) ([]T, int, error) {
+	next, err := harborFirstPage(path)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	var all []T
+	reported := -1
+	seen := map[string]bool{}
+	for next != "" && !seen[harborPageKey(next)] {
+		seen[harborPageKey(next)] = true
+
+		var page []T
+		header, err := h.getJSON(next, &page)
+		if err != nil {
+			return all, reported, err
+		}
+
+		if total, errTotal := strconv.Atoi(header.Get("X-Total-Count")); errTotal == nil {
+			reported = total
+		}
+
+		all = append(all, page...)
+		if len(page) == 0 {
+			break
+		}
+		next = harborNextPage(header.Get("Link"))
+	}
+	return all, reported, nil
+}
+
+func harborFirstPage(path string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid harbor path %q: %w", path, err)
+	}
+	q := u.Query()
+	q.Set("page", "1")
+	q.Set("page_size", strconv.Itoa(harborPageSize))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// harborPageKey returns page with its query parameters sorted, so links that
+// only order them differently are recognised as the same page.
+func harborPageKey(page string) string {
+	u, err := url.Parse(page)
+	if err != nil {
+		return page
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
+
+// harborNextPage extracts the rel="next" target from a Link header such as
+// `</api/v2.0/registries?page=1&page_size=10>; rel="prev" , </api/v2.0/registries?page=3&page_size=10>; rel="next"`.
+func harborNextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range segments[1:] {
+			if strings.ReplaceAll(strings.TrimSpace(param), " ", "") == `rel="next"` {
+				return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package exporters
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHarborNextPage(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{
+			name: "empty header",
+			link: "",
+			want: "",
+		},
+		{
+			name: "next only",
+			link: `</api/v2.0/registries?page=2&page_size=10>; rel="next"`,
+			want: "/api/v2.0/registries?page=2&page_size=10",
+		},
+		{
+			name: "prev and next",
+			link: `</api/v2.0/registries?page=1&page_size=10>; rel="prev" , </api/v2.0/registries?page=3&page_size=10>; rel="next"`,
+			want: "/api/v2.0/registries?page=3&page_size=10",
+		},
+		{
+			name: "prev only on the last page",
+			link: `</api/v2.0/registries?page=2&page_size=10>; rel="prev"`,
+			want: "",
+		},
+		{
+			name: "spaces around rel",
+			link: `</api/v2.0/projects?page=2>; rel = "next"`,
+			want: "/api/v2.0/projects?page=2",
+		},
+		{
+			name: "target without brackets",
+			link: `/api/v2.0/projects?page=2; rel="next"`,
+			want: "",
+		},
+		{
+			name: "missing parameters",
+			link: `</api/v2.0/projects?page=2>`,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := harborNextPage(tt.link); got != tt.want {
+				t.Errorf("harborNextPage(%q) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHarborListAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		w.Header().Set("X-Total-Count", "3")
+		switch page {
+		case "1":
+			w.Header().Set("Link", `</api/v2.0/registries?page=2&page_size=100>; rel="next"`)
+			fmt.Fprint(w, `[{"name":"a","status":"healthy"},{"name":"b","status":"unhealthy"}]`)
+		case "2":
+			w.Header().Set("Link", `</api/v2.0/registries?page=1&page_size=100>; rel="prev"`)
+			fmt.Fprint(w, `[{"name":"c","status":"healthy"}]`)
+		default:
+			t.Errorf("unexpected page %q", page)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	h := &HarborCollector{HarborAddress: strings.TrimPrefix(srv.URL, "http://"), httpClient: srv.Client()}
+	registries, reported, err := harborListAll[registryEntry](h, "/api/v2.0/registries")
+	if err != nil {
+		t.Fatalf("harborListAll: %v", err)
+	}
+	if len(registries) != 3 || registries[2].Name != "c" {
+		t.Errorf("got registries %+v, want a, b and c", registries)
+	}
+	if reported != 3 {
+		t.Errorf("got reported %d, want 3", reported)
+	}
+}
+
+func TestHarborListAllStopsOnLoops(t *testing.T) {
+	tests := []struct {
+		name string
+		link map[string]string
+		want int
+	}{
+		{
+			name: "next points to itself",
+			link: map[string]string{"1": `</api/v2.0/registries?page=1&page_size=100>; rel="next"`},
+			want: 1,
+		},
+		{
+			name: "next points back to the first page",
+			link: map[string]string{
+				"1": `</api/v2.0/registries?page=2&page_size=100>; rel="next"`,
+				"2": `</api/v2.0/registries?page=1&page_size=100>; rel="next"`,
+			},
+			want: 2,
+		},
+		{
+			name: "next reorders the parameters of a fetched page",
+			link: map[string]string{
+				"1": `</api/v2.0/registries?page=2&page_size=100>; rel="next"`,
+				"2": `</api/v2.0/registries?page_size=100&page=2>; rel="next"`,
+			},
+			want: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests > 10 {
+					t.Errorf("harborListAll keeps following the Link header")
+				} else {
+					w.Header().Set("Link", tt.link[r.URL.Query().Get("page")])
+				}
+				fmt.Fprint(w, `[{"name":"a","status":"healthy"}]`)
+			}))
+			defer srv.Close()
+
+			h := &HarborCollector{HarborAddress: strings.TrimPrefix(srv.URL, "http://"), httpClient: srv.Client()}
+			registries, _, err := harborListAll[registryEntry](h, "/api/v2.0/registries")
+			if err != nil {
+				t.Fatalf("harborListAll: %v", err)
+			}
+			if requests != tt.want || len(registries) != tt.want {
+				t.Errorf("got %d requests and %d registries, want %d of each", requests, len(registries), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package exporters
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type harborHealth struct {
+	Status     string `json:"status"`
+	Components []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	} `json:"components"`
+}
+
+type harborJobQueue struct {
+	JobType string  `json:"job_type"`
+	Count   int     `json:"count"`
+	Latency float64 `json:"latency"`
+	Paused  bool    `json:"paused"`
+}
+
+func healthValue(status string) float64 {
+	if status == "healthy" {
+		return 1
+	}
+	return 0
+}
+
+func (h *HarborCollector) collectHealth(ch chan<- prometheus.Metric) error {
+	var health harborHealth
+	if _, err := h.getJSON("/api/v2.0/health", &health); err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(h.HealthStatus, prometheus.GaugeValue, healthValue(health.Status))
+	for _, c := range health.Components {
+		ch <- prometheus.MustNewConstMetric(h.ComponentHealthStatus, prometheus.GaugeValue, healthValue(c.Status), c.Name)
+	}
+	return nil
+}
+
+func (h *HarborCollector) collectJobQueues(ch chan<- prometheus.Metric) error {
+	var queues []harborJobQueue
+	if _, err := h.getJSON("/api/v2.0/jobservice/queues", &queues); err != nil {
+		return err
+	}
+
+	for _, q := range queues {
+		paused := 0.0
+		if q.Paused {
+			paused = 1
+		}
+		ch <- prometheus.MustNewConstMetric(h.JobQueueDepth, prometheus.GaugeValue, float64(q.Count), q.JobType)
+		ch <- prometheus.MustNewConstMetric(h.JobQueueLatency, prometheus.GaugeValue, q.Latency, q.JobType)
+		ch <- prometheus.MustNewConstMetric(h.JobQueuePaused, prometheus.GaugeValue, paused, q.JobType)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package exporters
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	gcDeletedRegex = regexp.MustCompile(`(\d+) blobs and (\d+) manifests are actually deleted`)
+	gcFreedRegex   = regexp.MustCompile(`actual frees up (\d+) MB space`)
+)
+
+type harborGCRun struct {
+	ID           int       `json:"id"`
+	JobStatus    string    `json:"job_status"`
+	CreationTime time.Time `json:"creation_time"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
+type harborRetentionExecution struct {
+	ID        int       `json:"id"`
+	Status    string    `json:"status"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type harborRetentionTask struct {
+	Total    int `json:"total"`
+	Retained int `json:"retained"`
+}
+
+func jobFinished(status string) bool {
+	switch strings.ToLower(status) {
+	case "success", "succeed", "error", "stopped":
+		return true
+	}
+	return false
+}
+
+func (h *HarborCollector) collectGarbageCollection(ch chan<- prometheus.Metric) error {
+	var runs []harborGCRun
+	if _, err := h.getJSON("/api/v2.0/system/gc?sort=-creation_time&page=1&page_size=1", &runs); err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+
+	last := runs[0]
+	ch <- prometheus.MustNewConstMetric(h.GCLastRunStatus, prometheus.GaugeValue, 1, last.JobStatus)
+	ch <- prometheus.MustNewConstMetric(h.GCLastRunStart, prometheus.GaugeValue, float64(last.CreationTime.Unix()))
+	if !jobFinished(last.JobStatus) {
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(h.GCLastRunEnd, prometheus.GaugeValue, float64(last.UpdateTime.Unix()))
+
+	log, _, err := h.get(fmt.Sprintf("/api/v2.0/system/gc/%d/log", last.ID), "text/plain")
+	if err != nil {
+		return err
+	}
+	if m := gcFreedRegex.FindSubmatch(log); m != nil {
+		mb, _ := strconv.ParseFloat(string(m[1]), 64)
+		ch <- prometheus.MustNewConstMetric(h.GCLastRunFreedBytes, prometheus.GaugeValue, mb*1024*1024)
+	}
+	if m := gcDeletedRegex.FindSubmatch(log); m != nil {
+		manifests, _ := strconv.ParseFloat(string(m[2]), 64)
+		ch <- prometheus.MustNewConstMetric(h.GCLastRunDeletedArtifacts, prometheus.GaugeValue, manifests)
+	}
+	return nil
+}
+
+func (h *HarborCollector) collectRetention(s *harborScrape, ch chan<- prometheus.Metric) error {
+	projects, err := s.listProjects()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		if p.Metadata.RetentionID == "" {
+			continue
+		}
+
+		var executions []harborRetentionExecution
+		basePath := "/api/v2.0/retentions/" + p.Metadata.RetentionID + "/executions"
+		if _, err := h.getJSON(basePath+"?page=1&page_size=1", &executions); err != nil {
+			return err
+		}
+		if len(executions) == 0 {
+			continue
+		}
+
+		last := executions[0]
+		ch <- prometheus.MustNewConstMetric(h.RetentionLastRunStatus, prometheus.GaugeValue, 1, p.Name, last.Status)
+		ch <- prometheus.MustNewConstMetric(h.RetentionLastRunStart, prometheus.GaugeValue, float64(last.StartTime.Unix()), p.Name)
+		if !jobFinished(last.Status) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(h.RetentionLastRunEnd, prometheus.GaugeValue, float64(last.EndTime.Unix()), p.Name)
+
+		tasks, _, err := harborListAll[harborRetentionTask](h, fmt.Sprintf("%s/%d/tasks", basePath, last.ID))
+		if err != nil {
+			return err
+		}
+		deleted := 0
+		for _, t := range tasks {
+			deleted += t.Total - t.Retained
+		}
+		ch <- prometheus.MustNewConstMetric(h.RetentionLastRunDeletedArtifacts, prometheus.GaugeValue, float64(deleted), p.Name)
+	}
+	return nil
+}
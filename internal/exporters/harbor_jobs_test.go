@@ -0,0 +1,144 @@
+package exporters
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollectGarbageCollection(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		log         string
+		wantFreed   map[string]float64
+		wantDeleted map[string]float64
+	}{
+		{
+			name:   "finished run",
+			status: "Success",
+			log: `2024-03-01T02:00:00Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:137]: start to run gc in job.
+2024-03-01T02:00:01Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:346]: 12 blobs and 3 manifests eligible for deletion
+2024-03-01T02:00:01Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:347]: The GC could free up 300 MB space, the size is a rough estimate.
+2024-03-01T02:00:04Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:455]: 12 blobs and 3 manifests are actually deleted
+2024-03-01T02:00:04Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:456]: The GC job actual frees up 256 MB space.
+2024-03-01T02:00:04Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:191]: success to run gc in job.`,
+			wantFreed:   map[string]float64{"": 256 * 1024 * 1024},
+			wantDeleted: map[string]float64{"": 3},
+		},
+		{
+			name:   "nothing to delete",
+			status: "Success",
+			log: `2024-03-01T02:00:01Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:455]: 0 blobs and 0 manifests are actually deleted
+2024-03-01T02:00:01Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:456]: The GC job actual frees up 0 MB space.`,
+			wantFreed:   map[string]float64{"": 0},
+			wantDeleted: map[string]float64{"": 0},
+		},
+		{
+			name:   "dry run only estimates",
+			status: "Success",
+			log: `2024-03-01T02:00:01Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:346]: 12 blobs and 3 manifests eligible for deletion
+2024-03-01T02:00:01Z [INFO] [/jobservice/job/impl/gc/garbage_collection.go:347]: The GC could free up 300 MB space, the size is a rough estimate.`,
+			wantFreed:   map[string]float64{},
+			wantDeleted: map[string]float64{},
+		},
+		{
+			name:        "running job",
+			status:      "Running",
+			wantFreed:   map[string]float64{},
+			wantDeleted: map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarborCollector(t, HarborInstance{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v2.0/system/gc":
+					fmt.Fprintf(w, `[{"id":42,"job_status":%q,"creation_time":"2024-03-01T02:00:00Z","update_time":"2024-03-01T02:00:04Z"}]`, tt.status)
+				case "/api/v2.0/system/gc/42/log":
+					if tt.log == "" {
+						t.Errorf("fetched the log of an unfinished run")
+					}
+					fmt.Fprint(w, tt.log)
+				default:
+					t.Errorf("unexpected request %s", r.URL)
+					http.NotFound(w, r)
+				}
+			}))
+
+			freed := collectHarborValues(t, h.collectGarbageCollection, h.GCLastRunFreedBytes)
+			if fmt.Sprint(freed) != fmt.Sprint(tt.wantFreed) {
+				t.Errorf("got freed bytes %v, want %v", freed, tt.wantFreed)
+			}
+			deleted := collectHarborValues(t, h.collectGarbageCollection, h.GCLastRunDeletedArtifacts)
+			if fmt.Sprint(deleted) != fmt.Sprint(tt.wantDeleted) {
+				t.Errorf("got deleted artifacts %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestCollectRetention(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		tasks  string
+		want   map[string]float64
+	}{
+		{
+			name:   "deleted is total minus retained over all tasks",
+			status: "Succeed",
+			tasks:  `[{"total":10,"retained":4},{"total":3,"retained":3},{"total":5,"retained":0}]`,
+			want:   map[string]float64{"app": 11},
+		},
+		{
+			name:   "nothing deleted",
+			status: "Succeed",
+			tasks:  `[{"total":2,"retained":2}]`,
+			want:   map[string]float64{"app": 0},
+		},
+		{
+			name:   "no tasks",
+			status: "Succeed",
+			tasks:  `[]`,
+			want:   map[string]float64{"app": 0},
+		},
+		{
+			name:   "running execution",
+			status: "Running",
+			want:   map[string]float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHarborCollector(t, HarborInstance{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/v2.0/projects":
+					fmt.Fprint(w, `[{"project_id":1,"name":"app","metadata":{"retention_id":"7"}},{"project_id":2,"name":"infra","metadata":{}}]`)
+				case "/api/v2.0/retentions/7/executions":
+					fmt.Fprintf(w, `[{"id":5,"status":%q,"start_time":"2024-03-01T02:00:00Z","end_time":"2024-03-01T02:01:00Z"}]`, tt.status)
+				case "/api/v2.0/retentions/7/executions/5/tasks":
+					if tt.tasks == "" {
+						t.Errorf("listed the tasks of an unfinished execution")
+					}
+					fmt.Fprint(w, tt.tasks)
+				default:
+					t.Errorf("unexpected request %s", r.URL)
+					http.NotFound(w, r)
+				}
+			}))
+
+			collect := func(ch chan<- prometheus.Metric) error {
+				return h.collectRetention(newHarborScrape(h), ch)
+			}
+			deleted := collectHarborValues(t, collect, h.RetentionLastRunDeletedArtifacts)
+			if fmt.Sprint(deleted) != fmt.Sprint(tt.want) {
+				t.Errorf("got deleted artifacts %v, want %v", deleted, tt.want)
+			}
+		})
+	}
+}
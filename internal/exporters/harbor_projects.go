@@ -0,0 +1,105 @@
+package exporters
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type harborProject struct {
+	ProjectID int    `json:"project_id"`
+	Name      string `json:"name"`
+	OwnerName string `json:"owner_name"`
+	RepoCount int    `json:"repo_count"`
+	Metadata  struct {
+		Public      string `json:"public"`
+		RetentionID string `json:"retention_id"`
+	} `json:"metadata"`
+}
+
+type harborQuota struct {
+	Ref struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"ref"`
+	Hard struct {
+		Storage int64 `json:"storage"`
+	} `json:"hard"`
+	Used struct {
+		Storage int64 `json:"storage"`
+	} `json:"used"`
+}
+
+func (h *HarborCollector) listProjects() ([]harborProject, error) {
+	projects, _, err := harborListAll[harborProject](h, "/api/v2.0/projects")
+	return projects, err
+}
+
+// harborScrape lists the projects and repositories of a Harbor at most once
+// per Collect, as several sub-collectors need them. A failed list is not
+// retried within the scrape.
+type harborScrape struct {
+	h               *HarborCollector
+	projects        []harborProject
+	projectsErr     error
+	projectsListed  bool
+	repositories    map[string][]harborRepository
+	repositoriesErr map[string]error
+}
+
+func newHarborScrape(h *HarborCollector) *harborScrape {
+	return &harborScrape{
+		h:               h,
+		repositories:    make(map[string][]harborRepository),
+		repositoriesErr: make(map[string]error),
+	}
+}
+
+func (s *harborScrape) listProjects() ([]harborProject, error) {
+	if !s.projectsListed {
+		s.projects, s.projectsErr = s.h.listProjects()
+		s.projectsListed = true
+	}
+	return s.projects, s.projectsErr
+}
+
+func (s *harborScrape) listRepositories(project string) ([]harborRepository, error) {
+	if err, ok := s.repositoriesErr[project]; ok {
+		return s.repositories[project], err
+	}
+	repositories, err := s.h.listRepositories(project)
+	s.repositories[project] = repositories
+	s.repositoriesErr[project] = err
+	return repositories, err
+}
+
+func (h *HarborCollector) collectProjects(s *harborScrape, ch chan<- prometheus.Metric) error {
+	projects, err := s.listProjects()
+	if err != nil {
+		return err
+	}
+
+	quotas, _, err := harborListAll[harborQuota](h, "/api/v2.0/quotas?reference=project")
+	if err != nil {
+		return err
+	}
+	quotaByProject := make(map[int]harborQuota, len(quotas))
+	for _, q := range quotas {
+		quotaByProject[q.Ref.ID] = q
+	}
+
+	for _, p := range projects {
+		public, _ := strconv.ParseBool(p.Metadata.Public)
+
+		ch <- prometheus.MustNewConstMetric(h.ProjectInfo, prometheus.GaugeValue, 1, p.Name, strconv.FormatBool(public), p.OwnerName)
+		ch <- prometheus.MustNewConstMetric(h.ProjectRepositories, prometheus.GaugeValue, float64(p.RepoCount), p.Name)
+
+		q, ok := quotaByProject[p.ProjectID]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(h.ProjectStorageUsed, prometheus.GaugeValue, float64(q.Used.Storage), p.Name)
+		ch <- prometheus.MustNewConstMetric(h.ProjectStorageQuota, prometheus.GaugeValue, float64(q.Hard.Storage), p.Name)
+	}
+	return nil
+}
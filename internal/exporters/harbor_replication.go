@@ -0,0 +1,99 @@
+package exporters
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type harborReplicationPolicy struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	SrcRegistry *struct {
+		Name string `json:"name"`
+	} `json:"src_registry"`
+	DestRegistry *struct {
+		Name string `json:"name"`
+	} `json:"dest_registry"`
+	Trigger *struct {
+		Type string `json:"type"`
+	} `json:"trigger"`
+}
+
+type harborReplicationExecution struct {
+	ID         int       `json:"id"`
+	Status     string    `json:"status"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Succeed    int       `json:"succeed"`
+	Failed     int       `json:"failed"`
+	InProgress int       `json:"in_progress"`
+	Stopped    int       `json:"stopped"`
+}
+
+func (h *HarborCollector) collectReplication(ch chan<- prometheus.Metric) error {
+	policies, _, err := harborListAll[harborReplicationPolicy](h, "/api/v2.0/replication/policies")
+	if err != nil {
+		return err
+	}
+
+	for _, p := range policies {
+		source, destination, trigger := "local", "local", ""
+		if p.SrcRegistry != nil {
+			source = p.SrcRegistry.Name
+		}
+		if p.DestRegistry != nil {
+			destination = p.DestRegistry.Name
+		}
+		if p.Trigger != nil {
+			trigger = p.Trigger.Type
+		}
+		ch <- prometheus.MustNewConstMetric(h.ReplicationPolicyInfo, prometheus.GaugeValue, 1, p.Name, strconv.FormatBool(p.Enabled), trigger, source, destination)
+
+		// Only the most recent page of executions is inspected, the last success
+		// is reported only when it falls within it.
+		var executions []harborReplicationExecution
+		path := fmt.Sprintf("/api/v2.0/replication/executions?policy_id=%d&sort=-start_time&page=1&page_size=%d", p.ID, harborPageSize)
+		if _, err := h.getJSON(path, &executions); err != nil {
+			return err
+		}
+		if len(executions) == 0 {
+			continue
+		}
+
+		last := executions[0]
+		ch <- prometheus.MustNewConstMetric(h.ReplicationLastExecutionStatus, prometheus.GaugeValue, 1, p.Name, last.Status)
+
+		end := last.EndTime
+		if end.IsZero() || end.Before(last.StartTime) {
+			end = time.Now()
+		}
+		ch <- prometheus.MustNewConstMetric(h.ReplicationLastExecutionDuration, prometheus.GaugeValue, end.Sub(last.StartTime).Seconds(), p.Name)
+
+		tasks := map[string]int{
+			"succeeded":   last.Succeed,
+			"failed":      last.Failed,
+			"in_progress": last.InProgress,
+			"stopped":     last.Stopped,
+		}
+		for state, count := range tasks {
+			ch <- prometheus.MustNewConstMetric(h.ReplicationLastExecutionTasks, prometheus.GaugeValue, float64(count), p.Name, state)
+		}
+
+		for _, e := range executions {
+			if e.Status != "Succeed" {
+				continue
+			}
+			finished := e.EndTime
+			if finished.IsZero() {
+				finished = e.StartTime
+			}
+			ch <- prometheus.MustNewConstMetric(h.ReplicationSinceLastSuccess, prometheus.GaugeValue, time.Since(finished).Seconds(), p.Name)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package exporters
+
+import (
+	"path"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HarborRepositories controls the per-repository inventory metrics. Project
+// filters accept shell patterns, an empty include list selects every project.
+// When TopN is positive only the TopN most pulled repositories are exported.
+type HarborRepositories struct {
+	Enabled         bool     `json:"enabled" yaml:"enabled"`
+	IncludeProjects []string `json:"include_projects" yaml:"include_projects"`
+	ExcludeProjects []string `json:"exclude_projects" yaml:"exclude_projects"`
+	TopN            int      `json:"top_n" yaml:"top_n"`
+}
+
+func (r HarborRepositories) selects(project string) bool {
+	return selectsProject(r.IncludeProjects, r.ExcludeProjects, project)
+}
+
+func (r HarborRepositories) top(repositories []projectRepository) ([]projectRepository, int) {
+	return topRepositories(repositories, r.TopN)
+}
+
+// selectsProject reports whether project matches none of the exclude patterns
+// and, when include is not empty, one of the include patterns.
+func selectsProject(include, exclude []string, project string) bool {
+	for _, pattern := range exclude {
+		if ok, _ := path.Match(pattern, project); ok {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if ok, _ := path.Match(pattern, project); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// topRepositories returns the n most pulled repositories, every repository
+// when n is not positive, along with the number left out. repositories is not
+// modified.
+func topRepositories(repositories []projectRepository, n int) ([]projectRepository, int) {
+	if n <= 0 || len(repositories) <= n {
+		return repositories, 0
+	}
+
+	sorted := append([]projectRepository(nil), repositories...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PullCount > sorted[j].PullCount
+	})
+	return sorted[:n], len(sorted) - n
+}
+
+type projectRepository struct {
+	project string
+	harborRepository
+}
+
+// selectedRepositories lists the projects picked by selects and the
+// repositories in them.
+func (s *harborScrape) selectedRepositories(selects func(project string) bool) ([]string, []projectRepository, error) {
+	projects, err := s.listProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	var selected []projectRepository
+	for _, p := range projects {
+		if !selects(p.Name) {
+			continue
+		}
+		repositories, err := s.listRepositories(p.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, p.Name)
+		for _, r := range repositories {
+			selected = append(selected, projectRepository{project: p.Name, harborRepository: r})
+		}
+	}
+	return names, selected, nil
+}
+
+func (h *HarborCollector) collectRepositories(s *harborScrape, ch chan<- prometheus.Metric) error {
+	_, repositories, err := s.selectedRepositories(h.repositories.selects)
+	if err != nil {
+		return err
+	}
+
+	selected, dropped := h.repositories.top(repositories)
+	ch <- prometheus.MustNewConstMetric(h.RepositoryInventoryDropped, prometheus.GaugeValue, float64(dropped))
+
+	for _, r := range selected {
+		artifacts, _, err := harborListAll[harborArtifact](h, repositoryPath(r.project, r.Name)+"/artifacts?with_tag=false&with_label=false")
+		if err != nil {
+			return err
+		}
+
+		var size int64
+		var lastPush time.Time
+		for _, a := range artifacts {
+			size += a.Size
+			if a.PushTime.After(lastPush) {
+				lastPush = a.PushTime
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(h.RepositoryArtifacts, prometheus.GaugeValue, float64(r.ArtifactCount), r.project, r.Name)
+		ch <- prometheus.MustNewConstMetric(h.RepositorySize, prometheus.GaugeValue, float64(size), r.project, r.Name)
+		ch <- prometheus.MustNewConstMetric(h.RepositoryPulls, prometheus.CounterValue, float64(r.PullCount), r.project, r.Name)
+		if !lastPush.IsZero() {
+			ch <- prometheus.MustNewConstMetric(h.RepositoryLastPush, prometheus.GaugeValue, float64(lastPush.Unix()), r.project, r.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package exporters
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHarborCollectListsProjectsOnce(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v2.0/projects":
+			fmt.Fprint(w, `[{"project_id":1,"name":"app","metadata":{"retention_id":"7"}}]`)
+		case "/api/v2.0/projects/app/repositories":
+			fmt.Fprint(w, `[{"name":"app/web","artifact_count":1,"pull_count":5}]`)
+		case "/api/v2.0/projects/app/repositories/web/artifacts":
+			fmt.Fprint(w, `[{"digest":"sha256:1","size":10,"push_time":"2024-01-01T00:00:00Z"}]`)
+		default:
+			// The other sub-collectors are not under test.
+			http.NotFound(w, r)
+		}
+	})
+
+	h := newTestHarborCollector(t, HarborInstance{
+		Repositories:    HarborRepositories{Enabled: true},
+		Vulnerabilities: HarborVulnerabilities{Enabled: true},
+	}, handler)
+
+	ch := make(chan prometheus.Metric, 1000)
+	h.Collect(ch)
+	close(ch)
+
+	if got := requests["/api/v2.0/projects"]; got != 1 {
+		t.Errorf("listed projects %d times, want once", got)
+	}
+	if got := requests["/api/v2.0/projects/app/repositories"]; got != 1 {
+		t.Errorf("listed repositories %d times, want once", got)
+	}
+}
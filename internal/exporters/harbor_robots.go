@@ -0,0 +1,59 @@
+package exporters
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type harborRobot struct {
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Disable     bool   `json:"disable"`
+	ExpiresAt   int64  `json:"expires_at"`
+	Permissions []struct {
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace"`
+	} `json:"permissions"`
+}
+
+// projects returns the projects a robot account has permissions on, joined
+// by a comma. System robots covering every project report "*".
+func (r harborRobot) projects() string {
+	var projects []string
+	for _, p := range r.Permissions {
+		if p.Kind == "project" {
+			projects = append(projects, p.Namespace)
+		}
+	}
+	sort.Strings(projects)
+	return strings.Join(projects, ",")
+}
+
+func (h *HarborCollector) collectRobots(ch chan<- prometheus.Metric) error {
+	robots, _, err := harborListAll[harborRobot](h, "/api/v2.0/robots")
+	if err != nil {
+		return err
+	}
+
+	for _, r := range robots {
+		project := r.projects()
+
+		disabled := 0.0
+		if r.Disable {
+			disabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(h.RobotDisabled, prometheus.GaugeValue, disabled, r.Name, r.Level, project)
+
+		// expires_at is -1 for robot accounts that never expire.
+		if r.ExpiresAt <= 0 {
+			continue
+		}
+		expiresAt := time.Unix(r.ExpiresAt, 0)
+		ch <- prometheus.MustNewConstMetric(h.RobotExpiry, prometheus.GaugeValue, float64(r.ExpiresAt), r.Name, r.Level, project)
+		ch <- prometheus.MustNewConstMetric(h.RobotDaysRemaining, prometheus.GaugeValue, time.Until(expiresAt).Hours()/24, r.Name, r.Level, project)
+	}
+	return nil
+}
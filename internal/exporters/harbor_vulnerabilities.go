@@ -0,0 +1,161 @@
+package exporters
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var harborSeverities = []string{"critical", "high", "medium", "low"}
+
+type harborRepository struct {
+	Name          string `json:"name"`
+	ArtifactCount int    `json:"artifact_count"`
+	PullCount     int    `json:"pull_count"`
+}
+
+type harborScanOverview struct {
+	ScanStatus string    `json:"scan_status"`
+	EndTime    time.Time `json:"end_time"`
+	Summary    struct {
+		Summary map[string]int `json:"summary"`
+	} `json:"summary"`
+}
+
+type harborArtifact struct {
+	Digest       string                        `json:"digest"`
+	Size         int64                         `json:"size"`
+	PushTime     time.Time                     `json:"push_time"`
+	ScanOverview map[string]harborScanOverview `json:"scan_overview"`
+}
+
+type harborScanner struct {
+	UUID      string `json:"uuid"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+	Disabled  bool   `json:"disabled"`
+}
+
+func (h *HarborCollector) listRepositories(project string) ([]harborRepository, error) {
+	repositories, _, err := harborListAll[harborRepository](h, "/api/v2.0/projects/"+url.PathEscape(project)+"/repositories")
+	return repositories, err
+}
+
+// repositoryPath returns the artifact API path of a repository. Harbor expects
+// the slashes inside repository names to be URL encoded twice.
+func repositoryPath(project, repository string) string {
+	name := strings.TrimPrefix(repository, project+"/")
+	return "/api/v2.0/projects/" + url.PathEscape(project) + "/repositories/" + url.PathEscape(url.PathEscape(name))
+}
+
+// HarborVulnerabilities controls the vulnerability metrics, which read the scan
+// overview of the newest artifact of every repository in the selected
+// projects. The project filters work like those of HarborRepositories but are
+// independent of them. Per-project totals are always exported, PerRepository
+// adds the per-repository series for the TopN most pulled repositories, or for
+// all of them when TopN is not positive.
+type HarborVulnerabilities struct {
+	Enabled         bool     `json:"enabled" yaml:"enabled"`
+	IncludeProjects []string `json:"include_projects" yaml:"include_projects"`
+	ExcludeProjects []string `json:"exclude_projects" yaml:"exclude_projects"`
+	PerRepository   bool     `json:"per_repository" yaml:"per_repository"`
+	TopN            int      `json:"top_n" yaml:"top_n"`
+}
+
+func (v HarborVulnerabilities) selects(project string) bool {
+	return selectsProject(v.IncludeProjects, v.ExcludeProjects, project)
+}
+
+func (h *HarborCollector) collectVulnerabilities(s *harborScrape, ch chan<- prometheus.Metric) error {
+	projects, repositories, err := s.selectedRepositories(h.vulnerabilities.selects)
+	if err != nil {
+		return err
+	}
+
+	perRepository := make(map[string]bool)
+	if h.vulnerabilities.PerRepository {
+		kept, _ := topRepositories(repositories, h.vulnerabilities.TopN)
+		for _, r := range kept {
+			perRepository[r.project+"/"+r.Name] = true
+		}
+	}
+
+	projectCounts := make(map[string]map[string]int, len(projects))
+	for _, p := range projects {
+		projectCounts[p] = make(map[string]int)
+	}
+
+	for _, r := range repositories {
+		// Only the newest artifact is asked for, reading every artifact of
+		// every repository does not fit in a scrape on a large registry.
+		var artifacts []harborArtifact
+		if _, err := h.getJSON(repositoryPath(r.project, r.Name)+"/artifacts?sort=-push_time&page=1&page_size=1&with_scan_overview=true&with_tag=false&with_label=false", &artifacts); err != nil {
+			return err
+		}
+
+		overview, ok := scannedOverview(artifacts)
+		if !ok {
+			continue
+		}
+
+		emit := perRepository[r.project+"/"+r.Name]
+		for _, severity := range harborSeverities {
+			count := severityCount(overview.Summary.Summary, severity)
+			projectCounts[r.project][severity] += count
+			if emit {
+				ch <- prometheus.MustNewConstMetric(h.RepositoryVulnerabilities, prometheus.GaugeValue, float64(count), r.project, r.Name, severity)
+			}
+		}
+		if emit && !overview.EndTime.IsZero() {
+			ch <- prometheus.MustNewConstMetric(h.RepositoryScanAge, prometheus.GaugeValue, time.Since(overview.EndTime).Seconds(), r.project, r.Name)
+		}
+	}
+
+	for _, p := range projects {
+		for _, severity := range harborSeverities {
+			ch <- prometheus.MustNewConstMetric(h.ProjectVulnerabilities, prometheus.GaugeValue, float64(projectCounts[p][severity]), p, severity)
+		}
+	}
+	return nil
+}
+
+// scannedOverview returns the first successful scan report of the artifacts.
+func scannedOverview(artifacts []harborArtifact) (harborScanOverview, bool) {
+	for _, a := range artifacts {
+		for _, overview := range a.ScanOverview {
+			if overview.ScanStatus == "Success" {
+				return overview, true
+			}
+		}
+	}
+	return harborScanOverview{}, false
+}
+
+func severityCount(summary map[string]int, severity string) int {
+	for name, count := range summary {
+		if strings.EqualFold(name, severity) {
+			return count
+		}
+	}
+	return 0
+}
+
+func (h *HarborCollector) collectScanners(ch chan<- prometheus.Metric) error {
+	scanners, _, err := harborListAll[harborScanner](h, "/api/v2.0/scanners")
+	if err != nil {
+		return err
+	}
+
+	for _, s := range scanners {
+		var metadata map[string]interface{}
+		value := 1.0
+		if _, err := h.getJSON("/api/v2.0/scanners/"+url.PathEscape(s.UUID)+"/metadata", &metadata); err != nil {
+			value = 0
+		}
+		ch <- prometheus.MustNewConstMetric(h.ScannerHealth, prometheus.GaugeValue, value, s.Name, strconv.FormatBool(s.IsDefault), strconv.FormatBool(s.Disabled))
+	}
+	return nil
+}
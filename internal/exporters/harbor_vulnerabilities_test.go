@@ -0,0 +1,111 @@
+package exporters
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestHarborCollector returns a collector for instance that talks to a
+// test server running handler.
+func newTestHarborCollector(t *testing.T, instance HarborInstance, handler http.Handler) *HarborCollector {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	useTLS := false
+	instance.Name = "test"
+	instance.Address = strings.TrimPrefix(srv.URL, "http://")
+	instance.UseTLS = &useTLS
+	h, err := NewHarborCollector(instance)
+	if err != nil {
+		t.Fatalf("NewHarborCollector: %v", err)
+	}
+	return h
+}
+
+// collectHarborValues runs collect and returns the values of the metrics of
+// desc keyed by their variable label values joined with "/".
+func collectHarborValues(t *testing.T, collect func(chan<- prometheus.Metric) error, desc *prometheus.Desc) map[string]float64 {
+	t.Helper()
+	ch := make(chan prometheus.Metric, 1000)
+	if err := collect(ch); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	close(ch)
+
+	values := map[string]float64{}
+	for m := range ch {
+		if m.Desc() != desc {
+			continue
+		}
+		var out dto.Metric
+		if err := m.Write(&out); err != nil {
+			t.Fatalf("write metric: %v", err)
+		}
+		var labels []string
+		for _, l := range out.GetLabel() {
+			if l.GetName() != "harbor_instance" {
+				labels = append(labels, l.GetValue())
+			}
+		}
+		values[strings.Join(labels, "/")] = out.GetGauge().GetValue()
+	}
+	return values
+}
+
+func TestCollectVulnerabilities(t *testing.T) {
+	artifact := `[{"digest":"sha256:1","push_time":"2024-01-01T00:00:00Z","scan_overview":{"application/vnd.security.vulnerability.report; version=1.1":{"scan_status":"Success","summary":{"summary":{"Critical":%d,"High":1}}}}}]`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2.0/projects":
+			fmt.Fprint(w, `[{"name":"app"},{"name":"infra"}]`)
+		case "/api/v2.0/projects/app/repositories":
+			fmt.Fprint(w, `[{"name":"app/web","pull_count":5},{"name":"app/api","pull_count":9}]`)
+		case "/api/v2.0/projects/app/repositories/web/artifacts", "/api/v2.0/projects/app/repositories/api/artifacts":
+			q := r.URL.Query()
+			if q.Get("sort") != "-push_time" || q.Get("page_size") != "1" {
+				t.Errorf("got artifact query %s, want only the newest artifact", r.URL.RawQuery)
+			}
+			critical := 2
+			if strings.Contains(r.URL.Path, "/repositories/api/") {
+				critical = 3
+			}
+			fmt.Fprintf(w, artifact, critical)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	})
+
+	h := newTestHarborCollector(t, HarborInstance{
+		// The repositories filters must not apply to vulnerabilities.
+		Repositories: HarborRepositories{IncludeProjects: []string{"infra"}},
+		Vulnerabilities: HarborVulnerabilities{
+			Enabled:         true,
+			IncludeProjects: []string{"app"},
+			PerRepository:   true,
+			TopN:            1,
+		},
+	}, handler)
+
+	collect := func(ch chan<- prometheus.Metric) error {
+		return h.collectVulnerabilities(newHarborScrape(h), ch)
+	}
+	projects := collectHarborValues(t, collect, h.ProjectVulnerabilities)
+	want := map[string]float64{"app/critical": 5, "app/high": 2, "app/medium": 0, "app/low": 0}
+	if fmt.Sprint(projects) != fmt.Sprint(want) {
+		t.Errorf("got project vulnerabilities %v, want %v", projects, want)
+	}
+
+	repositories := collectHarborValues(t, collect, h.RepositoryVulnerabilities)
+	want = map[string]float64{"app/app/api/critical": 3, "app/app/api/high": 1, "app/app/api/medium": 0, "app/app/api/low": 0}
+	if fmt.Sprint(repositories) != fmt.Sprint(want) {
+		t.Errorf("got repository vulnerabilities %v, want %v", repositories, want)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"context"
 
 	"github.com/gophercloud/gophercloud/v2"
-	"github.com/gophercloud/gophercloud/v2/openstack"
 	"github.com/gophercloud/gophercloud/v2/openstack/config"
 	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
 	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
@@ -18,76 +17,165 @@ import (
 type Cloud struct {
 	OpenstackName string `json:"openstack_name" yaml:"openstack_name"`
 	MetricName    string `json:"metric_name" yaml:"metric_name"`
+	// AggregateRoleAssignments exports role assignments as counts per
+	// project and role instead of one series per user or group.
+	AggregateRoleAssignments bool `json:"aggregate_role_assignments" yaml:"aggregate_role_assignments"`
+	// Quotas enables the compute, block storage and network quota collector.
+	Quotas bool `json:"quotas" yaml:"quotas"`
+	// Hypervisors enables the hypervisor and placement capacity collector.
+	Hypervisors bool `json:"hypervisors" yaml:"hypervisors"`
+	// Endpoints enables probing every endpoint of the service catalog.
+	Endpoints bool `json:"endpoints" yaml:"endpoints"`
+	// ExtraLabels lists the project Extra keys exported as project_info
+	// labels, team when empty. Projects missing a key get ExtraLabelDefault.
+	ExtraLabels       []string `json:"extra_labels" yaml:"extra_labels"`
+	ExtraLabelDefault string   `json:"extra_label_default" yaml:"extra_label_default"`
+	// ApplicationCredentials enables listing the application credentials of
+	// every user, which costs one request per user.
+	ApplicationCredentials bool `json:"application_credentials" yaml:"application_credentials"`
+	// Instances enables the instance inventory collector. InstanceInfo adds
+	// one instance_info series per instance, up to InstanceInfoLimit.
+	Instances         bool `json:"instances" yaml:"instances"`
+	InstanceInfo      bool `json:"instance_info" yaml:"instance_info"`
+	InstanceInfoLimit int  `json:"instance_info_limit" yaml:"instance_info_limit"`
+	// LoadBalancers enables the Octavia load balancer health collector.
+	LoadBalancers bool `json:"loadbalancers" yaml:"loadbalancers"`
+	// Network enables the Neutron router, floating IP and port collector.
+	Network bool `json:"network" yaml:"network"`
 }
 type Metric struct {
 	Name   string
+	Help   string
 	Labels []string
 	Metric *prometheus.Desc
 }
 
 type KeyStoneCollector struct {
-	metrics map[string]Metric
-	cloud   Cloud
+	metrics     map[string]Metric
+	cloud       Cloud
+	session     *cloudSession
+	extraLabels []string
 }
 
-func authenticate(cloud Cloud) (*gophercloud.ServiceClient, error) {
+func authenticate(cloud Cloud) (*gophercloud.ProviderClient, gophercloud.EndpointOpts, error) {
 	ctx := context.Background()
 	authOptions, endpointOptions, tlsConfig, err := clouds.Parse(clouds.WithCloudName(cloud.OpenstackName))
 	if err != nil {
 		fmt.Printf("could not parse cloud.yaml: %s\n", err)
-		return nil, err
+		return nil, endpointOptions, err
 	}
+	authOptions.AllowReauth = true
 
 	providerClient, errClient := config.NewProviderClient(ctx, authOptions, config.WithTLSConfig(tlsConfig))
 	if errClient != nil {
 		fmt.Printf("could not create provider client: %s\n", errClient)
-		return nil, errClient
+		return nil, endpointOptions, errClient
 	}
-	identityClient, errIdentity := openstack.NewIdentityV3(providerClient, endpointOptions)
-	if errIdentity != nil {
-		fmt.Printf("could not create identity client: %s\n", errIdentity)
-		return nil, errIdentity
-	}
-	return identityClient, nil
+	return providerClient, endpointOptions, nil
 }
 
 func NewKeystoneCollector(cloud Cloud) *KeyStoneCollector {
+	extraLabels := uniqueExtraKeys(cloud.ExtraLabels)
+	if len(extraLabels) == 0 {
+		extraLabels = []string{"team"}
+	}
+	projectInfoLabels := []string{
+		"is_domain", "description", "domain_id", "enabled",
+		"id", "name", "parent_id", "tags",
+	}
+	projectInfoLabels = append(projectInfoLabels, extraLabelNames(projectInfoLabels, extraLabels)...)
+
 	projectMetrics := []Metric{
 		{Name: "projects"},
-		{Name: "project_info", Labels: []string{
-			"is_domain", "description", "domain_id", "enabled",
-			"id", "name", "parent_id", "tags", "team",
+		{Name: "project_info", Labels: projectInfoLabels},
+		{Name: "token_age_seconds", Help: "Age of the cached Keystone token"},
+		{Name: "authentications_total", Help: "Number of Keystone authentications"},
+		{Name: "auth_failures_total", Help: "Number of failed Keystone authentications"},
+		{Name: "domain_projects", Help: "Number of projects in a domain by enabled state", Labels: []string{
+			"domain_id", "domain_name", "enabled",
+		}},
+		{Name: "domain_users", Help: "Number of users in a domain", Labels: []string{
+			"domain_id", "domain_name",
+		}},
+		{Name: "project_depth", Help: "Depth of a project below its domain, 1 for projects directly under the domain", Labels: []string{
+			"id", "name", "domain_id",
+		}},
+		{Name: "project_children", Help: "Number of direct child projects of a project", Labels: []string{
+			"id", "name", "domain_id",
+		}},
+		{Name: "project_orphaned", Help: "Projects whose parent project or domain is disabled or missing", Labels: []string{
+			"id", "name", "parent_id",
+		}},
+		{Name: "users", Help: "Number of users"},
+		{Name: "user_info", Help: "User metadata", Labels: []string{
+			"id", "name", "domain_id", "enabled", "federated",
+		}},
+		{Name: "user_password_expiry_timestamp_seconds", Help: "Password expiry time of a local user, only set when PCI-DSS password expiry is enabled", Labels: []string{
+			"id", "name", "domain_id",
+		}},
+		{Name: "application_credential_info", Help: "Application credential of a user", Labels: []string{
+			"user_id", "user_name", "id", "name", "project_id", "unrestricted",
+		}},
+		{Name: "application_credential_expiry_timestamp_seconds", Help: "Expiry time of an application credential, absent for credentials that never expire", Labels: []string{
+			"user_id", "user_name", "id", "name", "project_id",
+		}},
+		{Name: "groups", Help: "Number of groups"},
+		{Name: "group_members", Help: "Number of users in a group", Labels: []string{
+			"id", "name", "domain_id",
 		}},
+		{Name: "role_assignment", Help: "Role assignment of a user or group on a project", Labels: []string{
+			"project_id", "project_name", "role", "role_id", "user_id", "user_name", "group_id", "group_name", "inherited",
+		}},
+		{Name: "role_assignments", Help: "Number of role assignments on a project by role", Labels: []string{
+			"project_id", "project_name", "role",
+		}},
+	}
+
+	return &KeyStoneCollector{
+		metrics:     newMetrics(cloud, projectMetrics),
+		cloud:       cloud,
+		session:     sessionFor(cloud),
+		extraLabels: extraLabels,
 	}
+}
 
+// newMetrics builds the descriptors of an OpenStack collector, named
+// apiexporter_<metric_name>_<name>.
+func newMetrics(cloud Cloud, definitions []Metric) map[string]Metric {
 	metrics := make(map[string]Metric)
-	for _, m := range projectMetrics {
+	for _, m := range definitions {
+		help := m.Help
+		if help == "" {
+			help = fmt.Sprintf("Description of %s", m.Name)
+		}
 		metrics[m.Name] = Metric{
 			Name:   m.Name,
+			Help:   help,
 			Labels: m.Labels,
 			Metric: prometheus.NewDesc(
 				prometheus.BuildFQName("apiexporter", cloud.MetricName, m.Name),
-				fmt.Sprintf("Description of %s", m.Name),
+				help,
 				m.Labels,
 				nil,
 			),
 		}
 	}
+	return metrics
+}
 
-	return &KeyStoneCollector{
-		metrics: metrics,
-		cloud:   cloud,
+func describeMetrics(metrics map[string]Metric, ch chan<- *prometheus.Desc) {
+	for _, m := range metrics {
+		ch <- m.Metric
 	}
 }
 
 func (kc *KeyStoneCollector) Describe(ch chan<- *prometheus.Desc) {
-	for _, m := range kc.metrics {
-		ch <- m.Metric
-	}
+	describeMetrics(kc.metrics, ch)
 }
 
 func (kc *KeyStoneCollector) Collect(ch chan<- prometheus.Metric) {
-	client, errAuth := authenticate(kc.cloud)
+	client, errAuth := kc.session.identityClient()
+	kc.collectSessionStats(ch)
 	if errAuth != nil {
 		fmt.Printf("Authentication error: %v\n", errAuth)
 		return
@@ -97,6 +185,7 @@ func (kc *KeyStoneCollector) Collect(ch chan<- prometheus.Metric) {
 	allPages, errList := projects.List(client, projects.ListOpts{}).AllPages(ctx)
 	if errList != nil {
 		fmt.Printf("Error listing projects: %v\n", errList)
+		kc.session.invalidate(errList)
 		return
 	}
 
@@ -117,17 +206,7 @@ func (kc *KeyStoneCollector) Collect(ch chan<- prometheus.Metric) {
 		if len(p.Tags) > 0 {
 			tagString = strings.Join(p.Tags, ",")
 		}
-		var team string
-		if p.Extra["team"] != nil {
-			team = p.Extra["team"].(string)
-		} else {
-			team = ""
-		}
-
-		ch <- prometheus.MustNewConstMetric(
-			kc.metrics["project_info"].Metric,
-			prometheus.GaugeValue,
-			1.0,
+		labelValues := []string{
 			strconv.FormatBool(p.IsDomain),
 			p.Description,
 			p.DomainID,
@@ -136,7 +215,45 @@ func (kc *KeyStoneCollector) Collect(ch chan<- prometheus.Metric) {
 			p.Name,
 			p.ParentID,
 			tagString,
-			team,
+		}
+		for _, key := range kc.extraLabels {
+			labelValues = append(labelValues, extraLabelValue(p.Extra[key], kc.cloud.ExtraLabelDefault))
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["project_info"].Metric,
+			prometheus.GaugeValue,
+			1.0,
+			labelValues...,
 		)
 	}
+
+	allUsers, usersListed := kc.collectUsers(ctx, client, ch)
+	kc.collectHierarchy(ctx, client, allProjects, allUsers, usersListed, ch)
+	kc.collectPasswordExpiry(allUsers, ch)
+	if kc.cloud.ApplicationCredentials {
+		kc.collectApplicationCredentials(ctx, client, allUsers, ch)
+	}
+	kc.collectGroups(ctx, client, ch)
+	kc.collectRoleAssignments(ctx, client, ch)
+}
+
+func (kc *KeyStoneCollector) collectSessionStats(ch chan<- prometheus.Metric) {
+	tokenAge, authentications, authFailures := kc.session.stats()
+
+	ch <- prometheus.MustNewConstMetric(
+		kc.metrics["token_age_seconds"].Metric,
+		prometheus.GaugeValue,
+		tokenAge.Seconds(),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		kc.metrics["authentications_total"].Metric,
+		prometheus.CounterValue,
+		float64(authentications),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		kc.metrics["auth_failures_total"].Metric,
+		prometheus.CounterValue,
+		float64(authFailures),
+	)
 }
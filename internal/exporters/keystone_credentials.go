@@ -0,0 +1,74 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/applicationcredentials"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (kc *KeyStoneCollector) collectPasswordExpiry(allUsers []users.User, ch chan<- prometheus.Metric) {
+	for _, u := range allUsers {
+		if u.PasswordExpiresAt.IsZero() {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["user_password_expiry_timestamp_seconds"].Metric,
+			prometheus.GaugeValue,
+			float64(u.PasswordExpiresAt.Unix()),
+			u.ID,
+			u.Name,
+			u.DomainID,
+		)
+	}
+}
+
+func (kc *KeyStoneCollector) collectApplicationCredentials(ctx context.Context, client *gophercloud.ServiceClient, allUsers []users.User, ch chan<- prometheus.Metric) {
+	for _, u := range allUsers {
+		allPages, errList := applicationcredentials.List(client, u.ID, applicationcredentials.ListOpts{}).AllPages(ctx)
+		if errList != nil {
+			fmt.Printf("Error listing application credentials of %s: %v\n", u.Name, errList)
+			kc.session.invalidate(errList)
+			continue
+		}
+
+		credentials, errExtract := applicationcredentials.ExtractApplicationCredentials(allPages)
+		if errExtract != nil {
+			fmt.Printf("Error extracting application credentials of %s: %v\n", u.Name, errExtract)
+			continue
+		}
+
+		for _, c := range credentials {
+			ch <- prometheus.MustNewConstMetric(
+				kc.metrics["application_credential_info"].Metric,
+				prometheus.GaugeValue,
+				1.0,
+				u.ID,
+				u.Name,
+				c.ID,
+				c.Name,
+				c.ProjectID,
+				strconv.FormatBool(c.Unrestricted),
+			)
+
+			if c.ExpiresAt.IsZero() {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				kc.metrics["application_credential_expiry_timestamp_seconds"].Metric,
+				prometheus.GaugeValue,
+				float64(c.ExpiresAt.Unix()),
+				u.ID,
+				u.Name,
+				c.ID,
+				c.Name,
+				c.ProjectID,
+			)
+		}
+	}
+}
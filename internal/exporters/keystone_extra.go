@@ -0,0 +1,64 @@
+package exporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// uniqueExtraKeys drops repeated project Extra keys, keeping the first one.
+func uniqueExtraKeys(keys []string) []string {
+	unique := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !slices.Contains(unique, key) {
+			unique = append(unique, key)
+		}
+	}
+	return unique
+}
+
+// extraLabelNames turns project Extra keys into valid label names. Names
+// starting with the reserved __ prefix and names that collide with an existing
+// label are prefixed with extra_, as often as needed to make them unique.
+func extraLabelNames(existing, keys []string) []string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name := invalidLabelChars.ReplaceAllString(key, "_")
+		if name == "" || (name[0] >= '0' && name[0] <= '9') {
+			name = "_" + name
+		}
+		if strings.HasPrefix(name, "__") {
+			name = "extra" + name
+		}
+		for slices.Contains(existing, name) || slices.Contains(names, name) {
+			name = "extra_" + name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// extraLabelValue stringifies a project Extra value of any JSON type.
+func extraLabelValue(value any, missing string) string {
+	switch v := value.(type) {
+	case nil:
+		return missing
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
@@ -0,0 +1,65 @@
+package exporters
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestExtraLabelNames(t *testing.T) {
+	existing := []string{"id", "name"}
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{
+			name: "plain keys",
+			keys: []string{"team", "cost-center"},
+			want: []string{"team", "cost_center"},
+		},
+		{
+			name: "collides with an existing label",
+			keys: []string{"name"},
+			want: []string{"extra_name"},
+		},
+		{
+			name: "repeated collisions",
+			keys: []string{"name", "name", "extra_name"},
+			want: []string{"extra_name", "extra_extra_name", "extra_extra_extra_name"},
+		},
+		{
+			name: "reserved prefix",
+			keys: []string{"__meta", "__name__"},
+			want: []string{"extra__meta", "extra__name__"},
+		},
+		{
+			name: "leading digit and empty key",
+			keys: []string{"1st", ""},
+			want: []string{"_1st", "_"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extraLabelNames(existing, tt.keys)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("extraLabelNames(%q) = %q, want %q", tt.keys, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeystoneCollectorRegistersWithAwkwardExtraLabels(t *testing.T) {
+	cloud := Cloud{
+		OpenstackName: "test",
+		MetricName:    "test",
+		ExtraLabels:   []string{"team", "team", "team", "__meta", "name", "extra_name"},
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(NewKeystoneCollector(cloud)); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+}
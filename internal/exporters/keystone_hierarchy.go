@@ -0,0 +1,122 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/domains"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxProjectDepth bounds the walk up the project tree in case of a cycle.
+const maxProjectDepth = 64
+
+func (kc *KeyStoneCollector) collectHierarchy(ctx context.Context, client *gophercloud.ServiceClient, allProjects []projects.Project, allUsers []users.User, usersListed bool, ch chan<- prometheus.Metric) {
+	allPages, errList := domains.List(client, domains.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing domains: %v\n", errList)
+		kc.session.invalidate(errList)
+		return
+	}
+
+	allDomains, errExtract := domains.ExtractDomains(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting domains: %v\n", errExtract)
+		return
+	}
+
+	domainByID := make(map[string]domains.Domain, len(allDomains))
+	for _, d := range allDomains {
+		domainByID[d.ID] = d
+	}
+	projectByID := make(map[string]projects.Project, len(allProjects))
+	children := make(map[string]int)
+	for _, p := range allProjects {
+		projectByID[p.ID] = p
+		children[p.ParentID]++
+	}
+
+	type domainState struct {
+		domainID string
+		enabled  bool
+	}
+	projectCounts := make(map[domainState]int)
+	for _, d := range allDomains {
+		projectCounts[domainState{d.ID, true}] = 0
+		projectCounts[domainState{d.ID, false}] = 0
+	}
+	userCounts := make(map[string]int)
+	for _, d := range allDomains {
+		userCounts[d.ID] = 0
+	}
+	for _, u := range allUsers {
+		userCounts[u.DomainID]++
+	}
+
+	for _, p := range allProjects {
+		projectCounts[domainState{p.DomainID, p.Enabled}]++
+
+		depth := 1
+		for parentID := p.ParentID; depth < maxProjectDepth; depth++ {
+			parent, ok := projectByID[parentID]
+			if !ok {
+				break
+			}
+			parentID = parent.ParentID
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["project_depth"].Metric,
+			prometheus.GaugeValue,
+			float64(depth),
+			p.ID, p.Name, p.DomainID,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["project_children"].Metric,
+			prometheus.GaugeValue,
+			float64(children[p.ID]),
+			p.ID, p.Name, p.DomainID,
+		)
+
+		parentEnabled := false
+		if parent, ok := projectByID[p.ParentID]; ok {
+			parentEnabled = parent.Enabled
+		} else if domain, ok := domainByID[p.ParentID]; ok {
+			parentEnabled = domain.Enabled
+		}
+		if !parentEnabled {
+			ch <- prometheus.MustNewConstMetric(
+				kc.metrics["project_orphaned"].Metric,
+				prometheus.GaugeValue,
+				1.0,
+				p.ID, p.Name, p.ParentID,
+			)
+		}
+	}
+
+	for key, count := range projectCounts {
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["domain_projects"].Metric,
+			prometheus.GaugeValue,
+			float64(count),
+			key.domainID, domainByID[key.domainID].Name, strconv.FormatBool(key.enabled),
+		)
+	}
+	// Without a user list every domain would report zero users, so
+	// domain_users is left out instead.
+	if !usersListed {
+		return
+	}
+	for domainID, count := range userCounts {
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["domain_users"].Metric,
+			prometheus.GaugeValue,
+			float64(count),
+			domainID, domainByID[domainID].Name,
+		)
+	}
+}
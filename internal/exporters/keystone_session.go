@@ -0,0 +1,172 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+)
+
+// tokenRefreshMargin is how long before its expiry a cached token is replaced.
+const tokenRefreshMargin = time.Minute
+
+// cloudSession keeps the provider client of a cloud across scrapes, so a new
+// Keystone token is only issued when the cached one is about to expire or has
+// been rejected.
+type cloudSession struct {
+	cloud Cloud
+
+	mu              sync.Mutex
+	provider        *gophercloud.ProviderClient
+	endpointOpts    gophercloud.EndpointOpts
+	issuedAt        time.Time
+	expiresAt       time.Time
+	authentications uint64
+	authFailures    uint64
+}
+
+var (
+	cloudSessions   = map[string]*cloudSession{}
+	cloudSessionsMu sync.Mutex
+)
+
+// sessionFor returns the session shared by every collector of a cloud.
+func sessionFor(cloud Cloud) *cloudSession {
+	cloudSessionsMu.Lock()
+	defer cloudSessionsMu.Unlock()
+
+	s, ok := cloudSessions[cloud.OpenstackName]
+	if !ok {
+		s = &cloudSession{cloud: cloud}
+		cloudSessions[cloud.OpenstackName] = s
+	}
+	return s
+}
+
+func (s *cloudSession) providerClient() (*gophercloud.ProviderClient, gophercloud.EndpointOpts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		s.refreshTokenTimes()
+		if time.Now().Before(s.expiresAt.Add(-tokenRefreshMargin)) {
+			return s.provider, s.endpointOpts, nil
+		}
+	}
+
+	provider, endpointOpts, err := authenticate(s.cloud)
+	if err != nil {
+		s.provider = nil
+		s.authFailures++
+		return nil, gophercloud.EndpointOpts{}, err
+	}
+	s.authentications++
+
+	// gophercloud re-authenticates on its own when a request is rejected
+	// with 401, count those as well.
+	if reauth := provider.ReauthFunc; reauth != nil {
+		provider.ReauthFunc = func(ctx context.Context) error {
+			err := reauth(ctx)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if err != nil {
+				s.authFailures++
+			} else {
+				s.authentications++
+			}
+			return err
+		}
+	}
+
+	s.provider = provider
+	s.endpointOpts = endpointOpts
+	s.refreshTokenTimes()
+	return s.provider, s.endpointOpts, nil
+}
+
+// refreshTokenTimes reads the issue and expiry time of the current token,
+// which changes whenever gophercloud re-authenticates.
+func (s *cloudSession) refreshTokenTimes() {
+	result, ok := s.provider.GetAuthResult().(tokens.CreateResult)
+	if !ok {
+		s.issuedAt, s.expiresAt = time.Time{}, time.Time{}
+		return
+	}
+
+	var token struct {
+		IssuedAt  time.Time `json:"issued_at"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := result.ExtractInto(&token); err != nil {
+		fmt.Printf("could not extract token of %s: %s\n", s.cloud.OpenstackName, err)
+		s.issuedAt, s.expiresAt = time.Time{}, time.Time{}
+		return
+	}
+	s.issuedAt, s.expiresAt = token.IssuedAt, token.ExpiresAt
+}
+
+func (s *cloudSession) identityClient() (*gophercloud.ServiceClient, error) {
+	return s.serviceClient("identity", openstack.NewIdentityV3)
+}
+
+// serviceClient builds a client for one OpenStack service out of the cached
+// provider client, e.g. serviceClient("compute", openstack.NewComputeV2).
+func (s *cloudSession) serviceClient(service string, newClient func(*gophercloud.ProviderClient, gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error)) (*gophercloud.ServiceClient, error) {
+	provider, endpointOpts, err := s.providerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	client, errClient := newClient(provider, endpointOpts)
+	if errClient != nil {
+		fmt.Printf("could not create %s client: %s\n", service, errClient)
+		return nil, errClient
+	}
+	return client, nil
+}
+
+// serviceCatalog returns the service catalog of the cached token along with
+// the HTTP client the provider client was configured with.
+func (s *cloudSession) serviceCatalog() (*tokens.ServiceCatalog, http.Client, error) {
+	provider, _, err := s.providerClient()
+	if err != nil {
+		return nil, http.Client{}, err
+	}
+
+	result, ok := provider.GetAuthResult().(tokens.CreateResult)
+	if !ok {
+		return nil, http.Client{}, fmt.Errorf("no service catalog available for %s", s.cloud.OpenstackName)
+	}
+	catalog, errCatalog := result.ExtractServiceCatalog()
+	if errCatalog != nil {
+		return nil, http.Client{}, errCatalog
+	}
+	return catalog, provider.HTTPClient, nil
+}
+
+// invalidate drops the cached provider client when err shows that the token
+// was rejected, so the next scrape authenticates from scratch.
+func (s *cloudSession) invalidate(err error) {
+	if !gophercloud.ResponseCodeIs(err, http.StatusUnauthorized) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = nil
+}
+
+func (s *cloudSession) stats() (tokenAge time.Duration, authentications, authFailures uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil && !s.issuedAt.IsZero() {
+		tokenAge = time.Since(s.issuedAt)
+	}
+	return tokenAge, s.authentications, s.authFailures
+}
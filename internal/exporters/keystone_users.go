@@ -0,0 +1,187 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/groups"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/roles"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/users"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// isFederated reports whether a user is backed by an identity provider.
+// Keystone lists the identity provider mappings of such users in "federated".
+func isFederated(u users.User) bool {
+	federated, ok := u.Extra["federated"].([]any)
+	return ok && len(federated) > 0
+}
+
+// collectUsers returns the users of the cloud and whether they could be
+// listed, so callers can tell a failed list from a cloud without users.
+func (kc *KeyStoneCollector) collectUsers(ctx context.Context, client *gophercloud.ServiceClient, ch chan<- prometheus.Metric) ([]users.User, bool) {
+	allPages, errList := users.List(client, users.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing users: %v\n", errList)
+		kc.session.invalidate(errList)
+		return nil, false
+	}
+
+	allUsers, errExtract := users.ExtractUsers(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting users: %v\n", errExtract)
+		return nil, false
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		kc.metrics["users"].Metric,
+		prometheus.GaugeValue,
+		float64(len(allUsers)),
+	)
+
+	for _, u := range allUsers {
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["user_info"].Metric,
+			prometheus.GaugeValue,
+			1.0,
+			u.ID,
+			u.Name,
+			u.DomainID,
+			strconv.FormatBool(u.Enabled),
+			strconv.FormatBool(isFederated(u)),
+		)
+	}
+	return allUsers, true
+}
+
+func (kc *KeyStoneCollector) collectGroups(ctx context.Context, client *gophercloud.ServiceClient, ch chan<- prometheus.Metric) {
+	allPages, errList := groups.List(client, groups.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing groups: %v\n", errList)
+		kc.session.invalidate(errList)
+		return
+	}
+
+	allGroups, errExtract := groups.ExtractGroups(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting groups: %v\n", errExtract)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		kc.metrics["groups"].Metric,
+		prometheus.GaugeValue,
+		float64(len(allGroups)),
+	)
+
+	for _, g := range allGroups {
+		memberPages, errMembers := users.ListInGroup(client, g.ID, users.ListOpts{}).AllPages(ctx)
+		if errMembers != nil {
+			fmt.Printf("Error listing members of group %s: %v\n", g.Name, errMembers)
+			continue
+		}
+		members, errExtractMembers := users.ExtractUsers(memberPages)
+		if errExtractMembers != nil {
+			fmt.Printf("Error extracting members of group %s: %v\n", g.Name, errExtractMembers)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["group_members"].Metric,
+			prometheus.GaugeValue,
+			float64(len(members)),
+			g.ID,
+			g.Name,
+			g.DomainID,
+		)
+	}
+}
+
+// roleAssignment adds the OS-INHERIT scope field, which gophercloud drops, to
+// a role assignment. Inherited assignments are listed on every project below
+// the one they were made on.
+type roleAssignment struct {
+	roles.RoleAssignment
+	Scope struct {
+		roles.Scope
+		InheritedTo string `json:"OS-INHERIT:inherited_to"`
+	} `json:"scope"`
+}
+
+func (kc *KeyStoneCollector) collectRoleAssignments(ctx context.Context, client *gophercloud.ServiceClient, ch chan<- prometheus.Metric) {
+	includeNames := true
+	allPages, errList := roles.ListAssignments(client, roles.ListAssignmentsOpts{IncludeNames: &includeNames}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing role assignments: %v\n", errList)
+		kc.session.invalidate(errList)
+		return
+	}
+
+	var assignments []roleAssignment
+	errExtract := allPages.(roles.RoleAssignmentPage).ExtractIntoSlicePtr(&assignments, "role_assignments")
+	if errExtract != nil {
+		fmt.Printf("Error extracting role assignments: %v\n", errExtract)
+		return
+	}
+
+	type projectRole struct {
+		projectID, projectName, role string
+	}
+	counts := make(map[projectRole]int)
+
+	// Keystone can return the same assignment more than once, e.g. when it is
+	// both made on a project and inherited from its parent. Every field of the
+	// key is also a label, so two assignments that differ only in a role
+	// sharing its name with another still end up in distinct series.
+	type assignmentKey struct {
+		projectID, roleID, userID, groupID string
+		inherited                          bool
+	}
+	seen := make(map[assignmentKey]bool)
+
+	for _, a := range assignments {
+		if a.Scope.Project.ID == "" {
+			continue
+		}
+
+		inherited := a.Scope.InheritedTo != ""
+		key := assignmentKey{a.Scope.Project.ID, a.Role.ID, a.User.ID, a.Group.ID, inherited}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if kc.cloud.AggregateRoleAssignments {
+			counts[projectRole{a.Scope.Project.ID, a.Scope.Project.Name, a.Role.Name}]++
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["role_assignment"].Metric,
+			prometheus.GaugeValue,
+			1.0,
+			a.Scope.Project.ID,
+			a.Scope.Project.Name,
+			a.Role.Name,
+			a.Role.ID,
+			a.User.ID,
+			a.User.Name,
+			a.Group.ID,
+			a.Group.Name,
+			strconv.FormatBool(inherited),
+		)
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(
+			kc.metrics["role_assignments"].Metric,
+			prometheus.GaugeValue,
+			float64(count),
+			key.projectID,
+			key.projectName,
+			key.role,
+		)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -42,8 +43,13 @@ type BaremetalDevice struct {
 }
 
 type InventoryItem struct {
+	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+
+	Device struct {
+		ID int `json:"id"`
+	} `json:"device"`
 }
 
 var ramGBRegex = regexp.MustCompile(`(?i)(\d+)\s*gb`)
@@ -74,28 +80,59 @@ type NetboxFetcher struct {
 	Token         string
 	UseTLS        bool
 	IgnoreTenants []string
+	// Backend selects how full snapshots are fetched, NetboxBackendREST when
+	// empty. NetboxBackendGraphQL needs NetBox 4.3 or later. Incremental
+	// refreshes always use the REST API.
+	Backend string
 
 	SnapshotPath string
 	Interval     time.Duration
+	Concurrency  NetboxConcurrency
+	// FullResyncInterval is how often the whole model is refetched. Runs in
+	// between only apply the changelog; every run is a full one when 0.
+	FullResyncInterval time.Duration
 
 	httpClient *http.Client
+	limiter    *netboxLimiter
 	logf       func(format string, args ...interface{})
+
+	fetchStatsMu sync.Mutex
+	fetchStats   map[string]*netboxFetchStats
+
+	// The model and the refresh bookkeeping are only touched by the loop.
+	model          *netboxModel
+	changelogPath  string
+	lastSync       time.Time
+	lastFullSync   time.Time
+	refreshes      map[string]int
+	changesApplied int
 }
 
 var lastSnapshotMemory []byte
 var lastSnapshotMu sync.RWMutex
 
-func StartNetboxFetcher(address, token string, useTLS bool, ignore []string) {
+func StartNetboxFetcher(address, token string, useTLS bool, ignore []string, backend string, concurrency NetboxConcurrency, fullResync time.Duration) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = concurrency.MaxPerHost
+	if concurrency.MaxPerHost > 0 {
+		transport.MaxIdleConnsPerHost = concurrency.MaxPerHost
+	}
+
 	f := &NetboxFetcher{
 		Address:       address,
 		Token:         token,
 		UseTLS:        useTLS,
 		IgnoreTenants: ignore,
+		Backend:       backend,
 
 		SnapshotPath: "/tmp/netbox.prom",
 		Interval:     5 * time.Minute,
+		Concurrency:  concurrency,
 
-		httpClient: &http.Client{Timeout: 20 * time.Second},
+		FullResyncInterval: fullResync,
+
+		httpClient: &http.Client{Timeout: 20 * time.Second, Transport: transport},
+		limiter:    newNetboxLimiter(concurrency),
 		logf: func(format string, args ...interface{}) {
 			fmt.Printf("[netbox-fetcher] "+format+"\n", args...)
 		},
@@ -105,7 +142,7 @@ func StartNetboxFetcher(address, token string, useTLS bool, ignore []string) {
 }
 
 func (f *NetboxFetcher) loop() {
-	f.logf("Starting NetBox fetcher (interval=%s)", f.Interval)
+	f.logf("Starting NetBox fetcher (interval=%s, backend=%s)", f.Interval, f.backend())
 
 	ticker := time.NewTicker(f.Interval)
 	defer ticker.Stop()
@@ -154,19 +191,30 @@ func (f *NetboxFetcher) run() {
 }
 
 func (f *NetboxFetcher) buildSnapshotMetrics() ([]byte, error) {
+	start := time.Now()
+	f.resetFetchStats()
 
-	tenants, err := f.fetchTenants()
+	model, err := f.refreshModel(start)
 	if err != nil {
 		return nil, err
 	}
-	tenants = filterTenants(tenants, f.IgnoreTenants)
 
 	buf := &bytes.Buffer{}
 	fmt.Fprintln(buf, "# NetBox Snapshot Exporter")
 
-	for _, t := range tenants {
+	writeSnapshotMetrics(buf, model)
+
+	f.writeFetchStats(buf)
+	f.writeRefreshStats(buf)
+	fmt.Fprintf(buf, "netbox_snapshot_build_duration_seconds %f\n", time.Since(start).Seconds())
+
+	return buf.Bytes(), nil
+}
 
-		devices, _ := f.fetchDevicesForTenant(t.Slug)
+func writeSnapshotMetrics(buf *bytes.Buffer, model *netboxModel) {
+	for _, t := range model.Tenants {
+
+		devices := model.Devices[t.Slug]
 
 		fmt.Fprintf(buf,
 			"netbox_tenant_baremetal_count{tenant=%q} %d\n",
@@ -182,7 +230,7 @@ func (f *NetboxFetcher) buildSnapshotMetrics() ([]byte, error) {
 				fmt.Sprint(d.ID), d.Name, d.Site.Name, d.Tenant.Slug, gen,
 			)
 
-			items, _ := f.fetchInventory(d.ID)
+			items := model.Inventory[d.ID]
 
 			ramTotal := 0.0
 			ramModules := []int{}
@@ -234,20 +282,28 @@ func (f *NetboxFetcher) buildSnapshotMetrics() ([]byte, error) {
 			}
 		}
 	}
-
-	return buf.Bytes(), nil
 }
 
 func (f *NetboxFetcher) fetchJSON(path string, dst interface{}) error {
+	return f.doJSON(http.MethodGet, path, nil, dst)
+}
+
+func (f *NetboxFetcher) doJSON(method, path string, payload io.Reader, dst interface{}) error {
 	schema := "http://"
 	if f.UseTLS {
 		schema = "https://"
 	}
 
 	url := schema + f.Address + path
-	req, _ := http.NewRequest("GET", url, nil)
+	req, _ := http.NewRequest(method, url, payload)
 	req.Header.Set("Authorization", "Token "+f.Token)
 	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	f.limiter.acquire()
+	defer f.limiter.release()
 
 	resp, err := f.httpClient.Do(req)
 	if err != nil {
@@ -258,36 +314,40 @@ func (f *NetboxFetcher) fetchJSON(path string, dst interface{}) error {
 	body, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("netbox http %d from %s: %s",
-			resp.StatusCode, path, string(body))
+		return &netboxHTTPError{Path: path, StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return json.Unmarshal(body, dst)
 }
 
 func (f *NetboxFetcher) fetchTenants() ([]Tenant, error) {
-	var obj struct {
-		Results []Tenant `json:"results"`
-	}
-	err := f.fetchJSON("/api/tenancy/tenants/?limit=2000", &obj)
-	return obj.Results, err
+	return netboxListAll[Tenant](f, "tenants", "/api/tenancy/tenants/")
 }
 
-func (f *NetboxFetcher) fetchDevicesForTenant(slug string) ([]BaremetalDevice, error) {
+func (f *NetboxFetcher) fetchServers() ([]BaremetalDevice, error) {
+	return netboxListAll[BaremetalDevice](f, "devices", "/api/dcim/devices/?role=server&expand=device_type")
+}
 
-	var obj struct {
-		Results []BaremetalDevice `json:"results"`
+// fetchServersByID lists the given devices that still exist and have the
+// server role.
+func (f *NetboxFetcher) fetchServersByID(ids []int) ([]BaremetalDevice, error) {
+	q := url.Values{}
+	q.Set("role", "server")
+	q.Set("expand", "device_type")
+	for _, id := range ids {
+		q.Add("id", strconv.Itoa(id))
 	}
-	err := f.fetchJSON("/api/dcim/devices/?role=server&tenant="+slug+"&limit=2000&expand=device_type", &obj)
-	return obj.Results, err
+	return netboxListAll[BaremetalDevice](f, "devices", "/api/dcim/devices/?"+q.Encode())
 }
 
-func (f *NetboxFetcher) fetchInventory(id int) ([]InventoryItem, error) {
-	var obj struct {
-		Results []InventoryItem `json:"results"`
+// fetchInventory lists the inventory items of several devices at once through
+// a multi-valued device_id filter.
+func (f *NetboxFetcher) fetchInventory(ids []int) ([]InventoryItem, error) {
+	q := url.Values{}
+	for _, id := range ids {
+		q.Add("device_id", strconv.Itoa(id))
 	}
-	err := f.fetchJSON(fmt.Sprintf("/api/dcim/inventory-items/?device_id=%d&limit=500", id), &obj)
-	return obj.Results, err
+	return netboxListAll[InventoryItem](f, "inventory_items", "/api/dcim/inventory-items/?"+q.Encode())
 }
 
 func filterTenants(all []Tenant, ignored []string) []Tenant {
@@ -0,0 +1,459 @@
+package exporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeNetboxDevice struct {
+	tenant, role string
+}
+
+type fakeNetboxItem struct {
+	device int
+	name   string
+}
+
+// fakeNetbox serves the tenant, device, inventory item and changelog endpoints
+// the fetcher uses from in-memory state that tests change between refreshes.
+type fakeNetbox struct {
+	t *testing.T
+
+	mu               sync.Mutex
+	tenants          []string
+	devices          map[int]fakeNetboxDevice
+	items            map[int]fakeNetboxItem
+	changes          []map[string]any
+	coreMissing      bool
+	changelogFailing bool
+	paths            []string
+	maxIDs           int
+}
+
+func newFakeNetbox(t *testing.T) *fakeNetbox {
+	return &fakeNetbox{
+		t:       t,
+		tenants: []string{"a", "b", "cloud"},
+		devices: map[int]fakeNetboxDevice{
+			1: {"a", "server"},
+			2: {"a", "server"},
+			4: {"cloud", "server"},
+			5: {"b", "switch"},
+		},
+		items: map[int]fakeNetboxItem{
+			10: {1, "RAM 32GB"},
+			11: {1, "RAM 32GB"},
+			12: {2, "SSD 960GB"},
+		},
+	}
+}
+
+func (n *fakeNetbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.paths = append(n.paths, r.URL.Path)
+	q := r.URL.Query()
+
+	var results []any
+	switch r.URL.Path {
+	case "/api/tenancy/tenants/":
+		for _, slug := range n.tenants {
+			results = append(results, map[string]any{"name": strings.ToUpper(slug), "slug": slug})
+		}
+	case "/api/dcim/devices/":
+		ids := n.ids(q["id"])
+		for _, id := range slices.Sorted(maps.Keys(n.devices)) {
+			d := n.devices[id]
+			if q.Get("role") != "" && d.role != q.Get("role") {
+				continue
+			}
+			if ids != nil && !ids[id] {
+				continue
+			}
+			device := map[string]any{"id": id, "name": "d" + strconv.Itoa(id), "device_type": map[string]any{"model": "DL380 Gen10"}}
+			if d.tenant != "" {
+				device["tenant"] = map[string]any{"name": strings.ToUpper(d.tenant), "slug": d.tenant}
+			}
+			results = append(results, device)
+		}
+	case "/api/dcim/inventory-items/":
+		ids := n.ids(q["device_id"])
+		for _, id := range slices.Sorted(maps.Keys(n.items)) {
+			it := n.items[id]
+			if ids != nil && !ids[it.device] {
+				continue
+			}
+			results = append(results, map[string]any{"id": id, "name": it.name, "description": "", "device": map[string]any{"id": it.device}})
+		}
+	case "/api/core/object-changes/", "/api/extras/object-changes/":
+		if q.Get("time_after") == "" {
+			n.t.Errorf("changelog requested without time_after")
+		}
+		if n.coreMissing && strings.HasPrefix(r.URL.Path, "/api/core/") {
+			http.NotFound(w, r)
+			return
+		}
+		if n.changelogFailing {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		for _, c := range n.changes {
+			results = append(results, c)
+		}
+	default:
+		n.t.Errorf("unexpected request %s", r.URL)
+		http.NotFound(w, r)
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"count": len(results), "next": nil, "results": results})
+}
+
+func (n *fakeNetbox) ids(values []string) map[int]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	n.maxIDs = max(n.maxIDs, len(values))
+	ids := map[int]bool{}
+	for _, v := range values {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			n.t.Errorf("invalid id %q", v)
+		}
+		ids[id] = true
+	}
+	return ids
+}
+
+func (n *fakeNetbox) requested(path string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return slices.Contains(n.paths, path)
+}
+
+func objectChange(objectType string, id int, related int, pre, post map[string]any) map[string]any {
+	change := map[string]any{
+		"changed_object_type": objectType,
+		"changed_object_id":   id,
+		"prechange_data":      pre,
+		"postchange_data":     post,
+	}
+	if related != 0 {
+		change["related_object_type"] = "dcim.device"
+		change["related_object_id"] = related
+	}
+	return change
+}
+
+// modelSummary lists the server IDs of every tenant and the number of
+// inventory items of every server.
+func modelSummary(m *netboxModel) (map[string][]int, map[int]int) {
+	devices := map[string][]int{}
+	inventory := map[int]int{}
+	for slug, servers := range m.Devices {
+		for _, d := range servers {
+			devices[slug] = append(devices[slug], d.ID)
+			inventory[d.ID] = len(m.Inventory[d.ID])
+		}
+		slices.Sort(devices[slug])
+	}
+	return devices, inventory
+}
+
+func TestNetboxIncrementalRefresh(t *testing.T) {
+	tests := []struct {
+		name          string
+		change        func(n *fakeNetbox)
+		wantMode      string
+		wantDevices   map[string][]int
+		wantInventory map[int]int
+	}{
+		{
+			name:          "no changes",
+			change:        func(n *fakeNetbox) {},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1, 2}},
+			wantInventory: map[int]int{1: 2, 2: 1},
+		},
+		{
+			name: "device deleted",
+			change: func(n *fakeNetbox) {
+				delete(n.devices, 2)
+				delete(n.items, 12)
+				n.changes = []map[string]any{objectChange("dcim.device", 2, 0, map[string]any{"tenant": 1}, nil)}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1}},
+			wantInventory: map[int]int{1: 2},
+		},
+		{
+			name: "device no longer a server",
+			change: func(n *fakeNetbox) {
+				n.devices[2] = fakeNetboxDevice{"a", "switch"}
+				n.changes = []map[string]any{objectChange("dcim.device", 2, 0, nil, nil)}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1}},
+			wantInventory: map[int]int{1: 2},
+		},
+		{
+			name: "device moved to another tenant",
+			change: func(n *fakeNetbox) {
+				n.devices[2] = fakeNetboxDevice{"b", "server"}
+				n.changes = []map[string]any{objectChange("dcim.device", 2, 0, nil, nil)}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1}, "b": {2}},
+			wantInventory: map[int]int{1: 2, 2: 1},
+		},
+		{
+			name: "device moved to an ignored tenant",
+			change: func(n *fakeNetbox) {
+				n.devices[2] = fakeNetboxDevice{"cloud", "server"}
+				n.changes = []map[string]any{objectChange("dcim.device", 2, 0, nil, nil)}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1}},
+			wantInventory: map[int]int{1: 2},
+		},
+		{
+			name: "device created with inventory",
+			change: func(n *fakeNetbox) {
+				n.devices[3] = fakeNetboxDevice{"b", "server"}
+				n.items[13] = fakeNetboxItem{3, "RAM 64GB"}
+				n.changes = []map[string]any{
+					objectChange("dcim.device", 3, 0, nil, nil),
+					objectChange("dcim.inventoryitem", 13, 3, nil, map[string]any{"device": 3}),
+				}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1, 2}, "b": {3}},
+			wantInventory: map[int]int{1: 2, 2: 1, 3: 1},
+		},
+		{
+			name: "inventory item moved between devices",
+			change: func(n *fakeNetbox) {
+				n.items[10] = fakeNetboxItem{2, "RAM 32GB"}
+				n.changes = []map[string]any{
+					objectChange("dcim.inventoryitem", 10, 2, map[string]any{"device": 1}, map[string]any{"device": 2}),
+				}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1, 2}},
+			wantInventory: map[int]int{1: 1, 2: 2},
+		},
+		{
+			name: "inventory item deleted",
+			change: func(n *fakeNetbox) {
+				delete(n.items, 11)
+				n.changes = []map[string]any{
+					objectChange("dcim.inventoryitem", 11, 1, map[string]any{"device": 1}, nil),
+				}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {1, 2}},
+			wantInventory: map[int]int{1: 1, 2: 1},
+		},
+		{
+			name: "changelog falls back to extras",
+			change: func(n *fakeNetbox) {
+				n.coreMissing = true
+				delete(n.devices, 1)
+				n.changes = []map[string]any{objectChange("dcim.device", 1, 0, nil, nil)}
+			},
+			wantMode:      "incremental",
+			wantDevices:   map[string][]int{"a": {2}},
+			wantInventory: map[int]int{2: 1},
+		},
+		{
+			name: "tenant change needs a full resync",
+			change: func(n *fakeNetbox) {
+				n.tenants = []string{"a", "cloud"}
+				n.changes = []map[string]any{objectChange("tenancy.tenant", 7, 0, nil, nil)}
+			},
+			wantMode:      "full",
+			wantDevices:   map[string][]int{"a": {1, 2}},
+			wantInventory: map[int]int{1: 2, 2: 1},
+		},
+		{
+			name: "device type change needs a full resync",
+			change: func(n *fakeNetbox) {
+				n.changes = []map[string]any{objectChange("dcim.devicetype", 3, 0, nil, nil)}
+			},
+			wantMode:      "full",
+			wantDevices:   map[string][]int{"a": {1, 2}},
+			wantInventory: map[int]int{1: 2, 2: 1},
+		},
+		{
+			name: "inventory change without a device needs a full resync",
+			change: func(n *fakeNetbox) {
+				delete(n.items, 12)
+				n.changes = []map[string]any{objectChange("dcim.inventoryitem", 12, 0, nil, nil)}
+			},
+			wantMode:      "full",
+			wantDevices:   map[string][]int{"a": {1, 2}},
+			wantInventory: map[int]int{1: 2, 2: 0},
+		},
+		{
+			name: "failing changelog falls back to a full resync",
+			change: func(n *fakeNetbox) {
+				n.changelogFailing = true
+				delete(n.devices, 2)
+			},
+			wantMode:      "full",
+			wantDevices:   map[string][]int{"a": {1}},
+			wantInventory: map[int]int{1: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			netbox := newFakeNetbox(t)
+			f := newTestNetboxFetcher(t, netbox)
+			f.IgnoreTenants = []string{"cloud"}
+			f.FullResyncInterval = time.Hour
+
+			start := time.Now()
+			if _, err := f.refreshModel(start); err != nil {
+				t.Fatalf("first refresh: %v", err)
+			}
+			if f.refreshes["full"] != 1 {
+				t.Fatalf("first refresh was not a full one: %v", f.refreshes)
+			}
+
+			netbox.mu.Lock()
+			tt.change(netbox)
+			netbox.mu.Unlock()
+
+			model, err := f.refreshModel(start.Add(5 * time.Minute))
+			if err != nil {
+				t.Fatalf("second refresh: %v", err)
+			}
+			if f.refreshes[tt.wantMode] != map[string]int{"full": 2, "incremental": 1}[tt.wantMode] {
+				t.Errorf("got refreshes %v, want the second one to be %s", f.refreshes, tt.wantMode)
+			}
+
+			devices, inventory := modelSummary(model)
+			for slug, ids := range devices {
+				if len(ids) == 0 {
+					delete(devices, slug)
+				}
+			}
+			if fmt.Sprint(devices) != fmt.Sprint(tt.wantDevices) {
+				t.Errorf("got servers %v, want %v", devices, tt.wantDevices)
+			}
+			if fmt.Sprint(inventory) != fmt.Sprint(tt.wantInventory) {
+				t.Errorf("got inventory counts %v, want %v", inventory, tt.wantInventory)
+			}
+		})
+	}
+}
+
+func TestNetboxChangelogFallbackIsRemembered(t *testing.T) {
+	netbox := newFakeNetbox(t)
+	netbox.coreMissing = true
+	f := newTestNetboxFetcher(t, netbox)
+
+	for i := 0; i < 2; i++ {
+		f.resetFetchStats()
+		if _, err := f.fetchChanges(time.Now()); err != nil {
+			t.Fatalf("fetchChanges: %v", err)
+		}
+		if stats := f.fetchStats["object_changes"]; stats == nil || stats.Truncated {
+			t.Errorf("got object_changes stats %+v, want a complete fetch", stats)
+		}
+	}
+
+	if f.changelogPath != "/api/extras/object-changes/" {
+		t.Errorf("got changelog path %q, want the extras endpoint", f.changelogPath)
+	}
+	core := 0
+	for _, path := range netbox.paths {
+		if path == "/api/core/object-changes/" {
+			core++
+		}
+	}
+	if core != 1 {
+		t.Errorf("core changelog requested %d times, want once", core)
+	}
+	if !netbox.requested("/api/extras/object-changes/") {
+		t.Error("extras changelog was never requested")
+	}
+}
+
+func TestNetboxFullResyncInterval(t *testing.T) {
+	netbox := newFakeNetbox(t)
+	f := newTestNetboxFetcher(t, netbox)
+	f.FullResyncInterval = time.Hour
+
+	start := time.Now()
+	for _, offset := range []time.Duration{0, 30 * time.Minute, 61 * time.Minute} {
+		if _, err := f.refreshModel(start.Add(offset)); err != nil {
+			t.Fatalf("refresh at %s: %v", offset, err)
+		}
+	}
+
+	if f.refreshes["full"] != 2 || f.refreshes["incremental"] != 1 {
+		t.Errorf("got refreshes %v, want 2 full and 1 incremental", f.refreshes)
+	}
+}
+
+func TestNetboxBulkDeviceChangesAreChunked(t *testing.T) {
+	netbox := newFakeNetbox(t)
+	f := newTestNetboxFetcher(t, netbox)
+	f.FullResyncInterval = time.Hour
+
+	start := time.Now()
+	if _, err := f.refreshModel(start); err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+
+	netbox.mu.Lock()
+	netbox.maxIDs = 0
+	for id := 100; id < 350; id++ {
+		netbox.devices[id] = fakeNetboxDevice{"b", "server"}
+		netbox.changes = append(netbox.changes, objectChange("dcim.device", id, 0, nil, nil))
+	}
+	netbox.mu.Unlock()
+
+	model, err := f.refreshModel(start.Add(5 * time.Minute))
+	if err != nil {
+		t.Fatalf("second refresh: %v", err)
+	}
+	if f.refreshes["incremental"] != 1 {
+		t.Errorf("got refreshes %v, want an incremental one", f.refreshes)
+	}
+	if got := len(model.Devices["b"]); got != 250 {
+		t.Errorf("got %d servers for tenant b, want 250", got)
+	}
+	if netbox.maxIDs > netboxIDChunk {
+		t.Errorf("a request carried %d IDs, want at most %d", netbox.maxIDs, netboxIDChunk)
+	}
+}
+
+func TestNetboxFullRefreshFailsOnInventoryErrors(t *testing.T) {
+	netbox := newFakeNetbox(t)
+	f := newTestNetboxFetcher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/dcim/inventory-items/" {
+			http.Error(w, "boom", http.StatusBadGateway)
+			return
+		}
+		netbox.ServeHTTP(w, r)
+	}))
+
+	if _, err := f.refreshModel(time.Now()); err == nil {
+		t.Fatal("full refresh succeeded despite failing inventory requests")
+	}
+	if f.model != nil {
+		t.Error("a model without inventory was cached")
+	}
+}
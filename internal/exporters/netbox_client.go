@@ -0,0 +1,245 @@
+package exporters
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// netboxPageSize is the limit requested on every NetBox list call. NetBox
+// clamps it to MAX_PAGE_SIZE, so the next links are followed regardless.
+const netboxPageSize = 1000
+
+// NetboxConcurrency bounds how hard a snapshot build hits NetBox. Workers is
+// the number of list calls made in parallel, MaxPerHost caps the requests in
+// flight and RequestsPerSecond rate limits them, unlimited when 0.
+type NetboxConcurrency struct {
+	Workers           int     `json:"workers" yaml:"workers"`
+	MaxPerHost        int     `json:"max_per_host" yaml:"max_per_host"`
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+}
+
+// netboxLimiter gates every NetBox request on a per-host slot and, when a
+// rate is set, on the next tick of a shared ticker.
+type netboxLimiter struct {
+	slots  chan struct{}
+	ticker *time.Ticker
+}
+
+func newNetboxLimiter(c NetboxConcurrency) *netboxLimiter {
+	l := &netboxLimiter{}
+	if c.MaxPerHost > 0 {
+		l.slots = make(chan struct{}, c.MaxPerHost)
+	}
+	if c.RequestsPerSecond > 0 {
+		l.ticker = time.NewTicker(time.Duration(float64(time.Second) / c.RequestsPerSecond))
+	}
+	return l
+}
+
+func (l *netboxLimiter) acquire() {
+	if l == nil {
+		return
+	}
+	if l.ticker != nil {
+		<-l.ticker.C
+	}
+	if l.slots != nil {
+		l.slots <- struct{}{}
+	}
+}
+
+func (l *netboxLimiter) release() {
+	if l == nil || l.slots == nil {
+		return
+	}
+	<-l.slots
+}
+
+// forEachParallel calls fn for every index below n from at most workers
+// goroutines and returns once all calls are done.
+func forEachParallel(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+type netboxHTTPError struct {
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *netboxHTTPError) Error() string {
+	return fmt.Sprintf("netbox http %d from %s: %s", e.StatusCode, e.Path, e.Body)
+}
+
+type netboxPage[T any] struct {
+	Count   int     `json:"count"`
+	Next    *string `json:"next"`
+	Results []T     `json:"results"`
+}
+
+// netboxFetchStats records how a snapshot build paged through one NetBox
+// list endpoint. Reported is only meaningful when Counted is set, GraphQL
+// lists come without a total.
+type netboxFetchStats struct {
+	Pages     int
+	Objects   int
+	Reported  int
+	Counted   bool
+	Truncated bool
+}
+
+// netboxListAll fetches every page of a NetBox list endpoint by following the
+// next links and records the page count under endpoint. A list is counted as
+// truncated when a page fails or fewer objects arrive than NetBox reported.
+func netboxListAll[T any](f *NetboxFetcher, endpoint, path string) ([]T, error) {
+	next, err := netboxFirstPage(path)
+	if err != nil {
+		f.recordFetch(endpoint, netboxFetchStats{Truncated: true})
+		return nil, err
+	}
+
+	var all []T
+	stats := netboxFetchStats{}
+	seen := map[string]bool{}
+	for next != "" && !seen[next] {
+		seen[next] = true
+
+		var page netboxPage[T]
+		if err := f.fetchJSON(next, &page); err != nil {
+			stats.Truncated = true
+			stats.Objects = len(all)
+			f.recordFetch(endpoint, stats)
+			return all, err
+		}
+
+		stats.Pages++
+		stats.Reported = page.Count
+		stats.Counted = true
+		all = append(all, page.Results...)
+		if len(page.Results) == 0 || page.Next == nil {
+			break
+		}
+
+		next, err = netboxNextPage(*page.Next)
+		if err != nil {
+			stats.Truncated = true
+			break
+		}
+	}
+
+	stats.Objects = len(all)
+	if stats.Objects < stats.Reported {
+		stats.Truncated = true
+	}
+	f.recordFetch(endpoint, stats)
+	return all, nil
+}
+
+func netboxFirstPage(path string) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid netbox path %q: %w", path, err)
+	}
+	q := u.Query()
+	q.Set("limit", strconv.Itoa(netboxPageSize))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// netboxNextPage turns the absolute next link of a NetBox response into a
+// path. NetBox behind a TLS-terminating proxy often advertises http:// links,
+// so only the path and query are kept and the fetcher's own scheme is used.
+func netboxNextPage(next string) (string, error) {
+	u, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("invalid netbox next link %q: %w", next, err)
+	}
+	return u.RequestURI(), nil
+}
+
+func (f *NetboxFetcher) resetFetchStats() {
+	f.fetchStatsMu.Lock()
+	defer f.fetchStatsMu.Unlock()
+	f.fetchStats = map[string]*netboxFetchStats{}
+}
+
+// recordFetch adds the stats of one list call to the totals of endpoint, which
+// may be listed several times per snapshot, e.g. once per tenant.
+func (f *NetboxFetcher) recordFetch(endpoint string, stats netboxFetchStats) {
+	f.fetchStatsMu.Lock()
+	defer f.fetchStatsMu.Unlock()
+
+	if f.fetchStats == nil {
+		f.fetchStats = map[string]*netboxFetchStats{}
+	}
+	total, ok := f.fetchStats[endpoint]
+	if !ok {
+		total = &netboxFetchStats{}
+		f.fetchStats[endpoint] = total
+	}
+	total.Pages += stats.Pages
+	total.Objects += stats.Objects
+	total.Reported += stats.Reported
+	total.Counted = total.Counted || stats.Counted
+	total.Truncated = total.Truncated || stats.Truncated
+}
+
+// discardFetch forgets the stats of endpoint, e.g. after probing an endpoint
+// that does not exist on this NetBox release.
+func (f *NetboxFetcher) discardFetch(endpoint string) {
+	f.fetchStatsMu.Lock()
+	defer f.fetchStatsMu.Unlock()
+	delete(f.fetchStats, endpoint)
+}
+
+func (f *NetboxFetcher) writeFetchStats(buf *bytes.Buffer) {
+	f.fetchStatsMu.Lock()
+	defer f.fetchStatsMu.Unlock()
+
+	endpoints := make([]string, 0, len(f.fetchStats))
+	for endpoint := range f.fetchStats {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	for _, endpoint := range endpoints {
+		stats := f.fetchStats[endpoint]
+		truncated := 0
+		if stats.Truncated {
+			truncated = 1
+		}
+		fmt.Fprintf(buf, "netbox_fetch_pages{endpoint=%q} %d\n", endpoint, stats.Pages)
+		fmt.Fprintf(buf, "netbox_fetch_objects{endpoint=%q} %d\n", endpoint, stats.Objects)
+		if stats.Counted {
+			fmt.Fprintf(buf, "netbox_fetch_reported_objects{endpoint=%q} %d\n", endpoint, stats.Reported)
+		}
+		fmt.Fprintf(buf, "netbox_fetch_truncated{endpoint=%q} %d\n", endpoint, truncated)
+	}
+}
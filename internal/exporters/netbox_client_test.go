@@ -0,0 +1,123 @@
+package exporters
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestNetboxFetcher(t *testing.T, handler http.Handler) *NetboxFetcher {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return &NetboxFetcher{
+		Address:    strings.TrimPrefix(srv.URL, "http://"),
+		httpClient: srv.Client(),
+		logf:       t.Logf,
+	}
+}
+
+func TestNetboxListAll(t *testing.T) {
+	tests := []struct {
+		name          string
+		pages         map[string]string
+		wantNames     []string
+		wantErr       bool
+		wantPages     int
+		wantTruncated bool
+	}{
+		{
+			name: "single page",
+			pages: map[string]string{
+				"": `{"count":2,"next":null,"results":[{"name":"a"},{"name":"b"}]}`,
+			},
+			wantNames: []string{"a", "b"},
+			wantPages: 1,
+		},
+		{
+			name: "follows next links with a foreign scheme and host",
+			pages: map[string]string{
+				"":  `{"count":3,"next":"http://netbox.internal/api/tenancy/tenants/?limit=1000&offset=2","results":[{"name":"a"},{"name":"b"}]}`,
+				"2": `{"count":3,"next":null,"results":[{"name":"c"}]}`,
+			},
+			wantNames: []string{"a", "b", "c"},
+			wantPages: 2,
+		},
+		{
+			name: "fewer objects than reported",
+			pages: map[string]string{
+				"": `{"count":5,"next":null,"results":[{"name":"a"}]}`,
+			},
+			wantNames:     []string{"a"},
+			wantPages:     1,
+			wantTruncated: true,
+		},
+		{
+			name: "next link pointing back to itself",
+			pages: map[string]string{
+				"":  `{"count":4,"next":"/api/tenancy/tenants/?limit=1000&offset=2","results":[{"name":"a"},{"name":"b"}]}`,
+				"2": `{"count":4,"next":"/api/tenancy/tenants/?limit=1000&offset=2","results":[{"name":"c"}]}`,
+			},
+			wantNames:     []string{"a", "b", "c"},
+			wantPages:     2,
+			wantTruncated: true,
+		},
+		{
+			name: "failing second page",
+			pages: map[string]string{
+				"": `{"count":3,"next":"/api/tenancy/tenants/?limit=1000&offset=2","results":[{"name":"a"},{"name":"b"}]}`,
+			},
+			wantNames:     []string{"a", "b"},
+			wantErr:       true,
+			wantPages:     1,
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestNetboxFetcher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/tenancy/tenants/" {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				if got := r.URL.Query().Get("limit"); got != fmt.Sprint(netboxPageSize) {
+					t.Errorf("got limit %q, want %d", got, netboxPageSize)
+				}
+				body, ok := tt.pages[r.URL.Query().Get("offset")]
+				if !ok {
+					http.Error(w, "boom", http.StatusInternalServerError)
+					return
+				}
+				fmt.Fprint(w, body)
+			}))
+
+			tenants, err := netboxListAll[Tenant](f, "tenants", "/api/tenancy/tenants/")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+
+			var names []string
+			for _, tenant := range tenants {
+				names = append(names, tenant.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("got tenants %q, want %q", names, tt.wantNames)
+			}
+
+			stats := f.fetchStats["tenants"]
+			if stats == nil {
+				t.Fatal("no fetch stats recorded")
+			}
+			if stats.Pages != tt.wantPages {
+				t.Errorf("got %d pages, want %d", stats.Pages, tt.wantPages)
+			}
+			if stats.Truncated != tt.wantTruncated {
+				t.Errorf("got truncated %t, want %t", stats.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package exporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The list queries fetch one page each. Devices and inventory items are
+// filtered by NetBox to the servers of the tenants that are not ignored, with
+// the lookup syntax of the GraphQL filters introduced in NetBox 4.3.
+const (
+	netboxGraphQLTenantQuery = `query($offset: Int!, $limit: Int!) {
+  tenant_list(pagination: {offset: $offset, limit: $limit}) { name slug }
+}`
+	netboxGraphQLDeviceQuery = `query($offset: Int!, $limit: Int!, $tenants: [String!]!) {
+  device_list(
+    filters: {role: {slug: {exact: "server"}}, tenant: {slug: {in_list: $tenants}}}
+    pagination: {offset: $offset, limit: $limit}
+  ) { id name site { name } tenant { name slug } role { name slug } device_type { model } }
+}`
+	netboxGraphQLInventoryQuery = `query($offset: Int!, $limit: Int!, $tenants: [String!]!) {
+  inventory_item_list(
+    filters: {device: {role: {slug: {exact: "server"}}, tenant: {slug: {in_list: $tenants}}}}
+    pagination: {offset: $offset, limit: $limit}
+  ) { id name description device { id } }
+}`
+)
+
+type netboxGraphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type netboxGraphQLDevice struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Site struct {
+		Name string `json:"name"`
+	} `json:"site"`
+	Tenant *struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"tenant"`
+	Role struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	} `json:"role"`
+	DeviceType struct {
+		Model string `json:"model"`
+	} `json:"device_type"`
+}
+
+type netboxGraphQLInventoryItem struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Device      struct {
+		ID string `json:"id"`
+	} `json:"device"`
+}
+
+// fetchModelGraphQL builds the same model as fetchModelREST from paginated
+// /graphql/ list queries.
+func (f *NetboxFetcher) fetchModelGraphQL() (*netboxModel, error) {
+	tenants, err := netboxGraphQLListAll[Tenant](f, "graphql_tenants", "tenant_list", netboxGraphQLTenantQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := filterTenants(tenants, f.IgnoreTenants)
+	slugs := make([]string, 0, len(kept))
+	for _, t := range kept {
+		slugs = append(slugs, t.Slug)
+	}
+	filter := map[string]interface{}{"tenants": slugs}
+
+	devices, err := netboxGraphQLListAll[netboxGraphQLDevice](f, "graphql_devices", "device_list", netboxGraphQLDeviceQuery, filter)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := netboxGraphQLListAll[netboxGraphQLInventoryItem](f, "graphql_inventory_items", "inventory_item_list", netboxGraphQLInventoryQuery, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []BaremetalDevice
+	for _, d := range devices {
+		if d.Tenant == nil {
+			continue
+		}
+		id, err := strconv.Atoi(d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid netbox device id %q: %w", d.ID, err)
+		}
+
+		server := BaremetalDevice{ID: id, Name: d.Name}
+		server.Site.Name = d.Site.Name
+		server.Tenant.Name = d.Tenant.Name
+		server.Tenant.Slug = d.Tenant.Slug
+		server.Role.Name = d.Role.Name
+		server.DeviceType.Model = d.DeviceType.Model
+		servers = append(servers, server)
+	}
+
+	model, _ := newNetboxModel(tenants, servers, f.IgnoreTenants)
+
+	items := make([]InventoryItem, 0, len(inventory))
+	for _, it := range inventory {
+		id, errID := strconv.Atoi(it.ID)
+		deviceID, errDevice := strconv.Atoi(it.Device.ID)
+		if errID != nil || errDevice != nil {
+			return nil, fmt.Errorf("invalid netbox inventory item id %q of device %q", it.ID, it.Device.ID)
+		}
+
+		item := InventoryItem{ID: id, Name: it.Name, Description: it.Description}
+		item.Device.ID = deviceID
+		items = append(items, item)
+	}
+	model.addInventory(items)
+
+	return model, nil
+}
+
+// netboxGraphQLListAll pages through the list of query until NetBox returns an
+// empty page, so a limit clamped by MAX_PAGE_SIZE is handled. variables are
+// sent along with the offset and limit of every page. A page equal to the
+// previous one means the pagination argument was ignored, and the list is
+// complete. GraphQL reports no totals, so a list only counts as truncated when
+// a page fails.
+func netboxGraphQLListAll[T any](f *NetboxFetcher, endpoint, list, query string, variables map[string]interface{}) ([]T, error) {
+	page := make(map[string]interface{}, len(variables)+2)
+	for name, value := range variables {
+		page[name] = value
+	}
+
+	var all []T
+	var previous json.RawMessage
+	stats := netboxFetchStats{}
+	for {
+		page["offset"] = len(all)
+		page["limit"] = netboxPageSize
+		data, err := f.queryGraphQL(query, page)
+		if err != nil {
+			stats.Truncated = true
+			stats.Objects = len(all)
+			f.recordFetch(endpoint, stats)
+			return all, err
+		}
+		stats.Pages++
+
+		raw := data[list]
+		if bytes.Equal(raw, previous) {
+			break
+		}
+		previous = raw
+
+		var objects []T
+		if err := json.Unmarshal(raw, &objects); err != nil {
+			stats.Truncated = true
+			stats.Objects = len(all)
+			f.recordFetch(endpoint, stats)
+			return all, fmt.Errorf("could not decode netbox graphql %s: %w", list, err)
+		}
+		if len(objects) == 0 {
+			break
+		}
+		all = append(all, objects...)
+	}
+
+	stats.Objects = len(all)
+	f.recordFetch(endpoint, stats)
+	return all, nil
+}
+
+// queryGraphQL posts query to /graphql/ and returns the data of the response.
+// GraphQL errors are returned even when NetBox answers with 200.
+func (f *NetboxFetcher) queryGraphQL(query string, variables interface{}) (map[string]json.RawMessage, error) {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp netboxGraphQLResponse
+	if err := f.doJSON(http.MethodPost, "/graphql/", bytes.NewReader(payload), &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return nil, fmt.Errorf("netbox graphql: %s", strings.Join(messages, "; "))
+	}
+
+	return resp.Data, nil
+}
@@ -0,0 +1,121 @@
+package exporters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphQLListHandler serves the given lists with offset pagination, returning
+// at most pageSize objects per page to mimic a clamped limit. The lists are
+// served as they are, filters are not applied. Every request is passed to
+// seen when it is not nil.
+func graphQLListHandler(t *testing.T, pageSize int, lists map[string][]string, seen func(graphQLRequest)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+			return
+		}
+		if seen != nil {
+			seen(req)
+		}
+
+		for name, objects := range lists {
+			if !strings.Contains(req.Query, name+"(") {
+				continue
+			}
+			requested, _ := req.Variables["offset"].(float64)
+			offset := min(int(requested), len(objects))
+			end := min(offset+pageSize, len(objects))
+			fmt.Fprintf(w, `{"data":{%q:[%s]}}`, name, strings.Join(objects[offset:end], ","))
+			return
+		}
+		t.Errorf("unexpected query %s", req.Query)
+	}
+}
+
+func TestFetchModelGraphQL(t *testing.T) {
+	var mu sync.Mutex
+	var requests []graphQLRequest
+	f := newTestNetboxFetcher(t, graphQLListHandler(t, 2, map[string][]string{
+		"tenant_list": {
+			`{"name":"A","slug":"a"}`,
+			`{"name":"B","slug":"b"}`,
+			`{"name":"Cloud","slug":"cloud"}`,
+		},
+		"device_list": {
+			`{"id":"1","name":"s1","site":{"name":"x"},"tenant":{"name":"A","slug":"a"},"role":{"name":"Server","slug":"server"},"device_type":{"model":"DL380 Gen10"}}`,
+			`{"id":"3","name":"s3","site":{"name":"x"},"tenant":{"name":"A","slug":"a"},"role":{"name":"Server","slug":"server"},"device_type":{"model":"DL380 Gen10"}}`,
+			`{"id":"5","name":"s5","site":{"name":"x"},"tenant":{"name":"B","slug":"b"},"role":{"name":"Server","slug":"server"},"device_type":{"model":"DL380 Gen11"}}`,
+		},
+		"inventory_item_list": {
+			`{"id":"10","name":"RAM 32GB","description":"","device":{"id":"1"}}`,
+			`{"id":"11","name":"RAM 32GB","description":"","device":{"id":"1"}}`,
+			`{"id":"12","name":"SSD 960GB","description":"","device":{"id":"5"}}`,
+		},
+	}, func(req graphQLRequest) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, req)
+	}))
+	f.IgnoreTenants = []string{"cloud"}
+
+	model, err := f.fetchModelGraphQL()
+	if err != nil {
+		t.Fatalf("fetchModelGraphQL: %v", err)
+	}
+
+	if len(model.Tenants) != 2 {
+		t.Errorf("got %d tenants, want 2", len(model.Tenants))
+	}
+	if got := len(model.Devices["a"]); got != 2 {
+		t.Errorf("got %d servers for tenant a, want 2", got)
+	}
+	if got := len(model.Devices["b"]); got != 1 {
+		t.Errorf("got %d servers for tenant b, want 1", got)
+	}
+	if got := len(model.Inventory[1]); got != 2 {
+		t.Errorf("got %d inventory items for device 1, want 2", got)
+	}
+
+	devices := f.fetchStats["graphql_devices"]
+	if devices == nil || devices.Objects != 3 || devices.Pages != 3 || devices.Counted || devices.Truncated {
+		t.Errorf("got device fetch stats %+v, want 3 objects over 3 pages without a total", devices)
+	}
+
+	// Devices and inventory items are filtered by NetBox to the servers of
+	// the tenants that are not ignored.
+	for _, req := range requests {
+		if strings.Contains(req.Query, "tenant_list(") {
+			continue
+		}
+		if !strings.Contains(req.Query, `role: {slug: {exact: "server"}}`) || !strings.Contains(req.Query, "tenant: {slug: {in_list: $tenants}}") {
+			t.Errorf("got query %s, want it filtered to servers of the kept tenants", req.Query)
+		}
+		if got := fmt.Sprint(req.Variables["tenants"]); got != "[a b]" {
+			t.Errorf("got tenants variable %s, want [a b]", got)
+		}
+	}
+}
+
+func TestFetchModelGraphQLErrors(t *testing.T) {
+	f := newTestNetboxFetcher(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":null,"errors":[{"message":"Unknown argument 'pagination'"}]}`)
+	}))
+
+	if _, err := f.fetchModelGraphQL(); err == nil || !strings.Contains(err.Error(), "pagination") {
+		t.Errorf("got error %v, want the GraphQL error message", err)
+	}
+	if stats := f.fetchStats["graphql_tenants"]; stats == nil || !stats.Truncated {
+		t.Errorf("got tenant fetch stats %+v, want truncated", stats)
+	}
+}
@@ -0,0 +1,150 @@
+package exporters
+
+import "fmt"
+
+// netboxIDChunk is the number of device IDs sent in one multi-valued filter
+// request, which keeps the query string well below common URL length limits.
+const netboxIDChunk = 100
+
+// netboxModel is everything a snapshot is rendered from: the tenants that are
+// not ignored, their servers keyed by tenant slug and the inventory items of
+// those servers keyed by device ID.
+type netboxModel struct {
+	Tenants   []Tenant
+	Devices   map[string][]BaremetalDevice
+	Inventory map[int][]InventoryItem
+}
+
+const (
+	NetboxBackendREST    = "rest"
+	NetboxBackendGraphQL = "graphql"
+)
+
+func (f *NetboxFetcher) backend() string {
+	if f.Backend == "" {
+		return NetboxBackendREST
+	}
+	return f.Backend
+}
+
+func (f *NetboxFetcher) fetchModel() (*netboxModel, error) {
+	switch f.backend() {
+	case NetboxBackendREST:
+		return f.fetchModelREST()
+	case NetboxBackendGraphQL:
+		return f.fetchModelGraphQL()
+	default:
+		return nil, fmt.Errorf("unknown netbox backend %q", f.Backend)
+	}
+}
+
+// newNetboxModel keeps the tenants that are not ignored and groups the servers
+// of those tenants by tenant slug, returning the IDs of the kept servers.
+func newNetboxModel(tenants []Tenant, servers []BaremetalDevice, ignore []string) (*netboxModel, []int) {
+	tenants = filterTenants(tenants, ignore)
+
+	model := &netboxModel{
+		Tenants:   tenants,
+		Devices:   make(map[string][]BaremetalDevice, len(tenants)),
+		Inventory: make(map[int][]InventoryItem),
+	}
+	return model, model.addServers(servers)
+}
+
+// addServers adds the servers that belong to one of the model's tenants and
+// returns their IDs.
+func (m *netboxModel) addServers(servers []BaremetalDevice) []int {
+	wanted := make(map[string]bool, len(m.Tenants))
+	for _, t := range m.Tenants {
+		wanted[t.Slug] = true
+	}
+
+	var ids []int
+	for _, d := range servers {
+		if !wanted[d.Tenant.Slug] {
+			continue
+		}
+		m.Devices[d.Tenant.Slug] = append(m.Devices[d.Tenant.Slug], d)
+		ids = append(ids, d.ID)
+	}
+	return ids
+}
+
+// removeDevices drops the given devices along with their inventory.
+func (m *netboxModel) removeDevices(ids map[int]bool) {
+	for slug, devices := range m.Devices {
+		kept := devices[:0]
+		for _, d := range devices {
+			if !ids[d.ID] {
+				kept = append(kept, d)
+			}
+		}
+		m.Devices[slug] = kept
+	}
+	for id := range ids {
+		delete(m.Inventory, id)
+	}
+}
+
+func (m *netboxModel) addInventory(items []InventoryItem) {
+	for _, it := range items {
+		m.Inventory[it.Device.ID] = append(m.Inventory[it.Device.ID], it)
+	}
+}
+
+// fetchModelREST lists tenants, servers and inventory items in bulk and joins
+// them in memory, so a snapshot costs a handful of paginated list calls
+// instead of one request per tenant and device.
+func (f *NetboxFetcher) fetchModelREST() (*netboxModel, error) {
+	tenants, err := f.fetchTenants()
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := f.fetchServers()
+	if err != nil {
+		return nil, err
+	}
+
+	model, ids := newNetboxModel(tenants, servers, f.IgnoreTenants)
+
+	// A failed chunk fails the whole refresh and keeps the previous snapshot,
+	// incremental refreshes would not refetch its devices until the next
+	// full resync.
+	items, err := fetchInChunks(f, ids, f.fetchInventory)
+	if err != nil {
+		return nil, err
+	}
+	model.addInventory(items)
+
+	return model, nil
+}
+
+// fetchInChunks calls fetch for every netboxIDChunk of ids from the worker
+// pool. The results of the chunks that succeeded are returned along with the
+// first error.
+func fetchInChunks[T any](f *NetboxFetcher, ids []int, fetch func([]int) ([]T, error)) ([]T, error) {
+	var chunks [][]int
+	for len(ids) > 0 {
+		n := min(netboxIDChunk, len(ids))
+		chunks = append(chunks, ids[:n])
+		ids = ids[n:]
+	}
+
+	results := make([][]T, len(chunks))
+	errs := make([]error, len(chunks))
+	forEachParallel(len(chunks), f.Concurrency.Workers, func(i int) {
+		results[i], errs[i] = fetch(chunks[i])
+	})
+
+	var all []T
+	for _, chunk := range results {
+		all = append(all, chunk...)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return all, err
+		}
+	}
+	return all, nil
+}
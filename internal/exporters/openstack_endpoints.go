@@ -0,0 +1,139 @@
+package exporters
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const endpointProbeTimeout = 10 * time.Second
+
+type EndpointCollector struct {
+	metrics map[string]Metric
+	cloud   Cloud
+	session *cloudSession
+}
+
+type endpointProbe struct {
+	status  int
+	latency time.Duration
+	err     error
+}
+
+func NewEndpointCollector(cloud Cloud) *EndpointCollector {
+	labels := []string{"service_type", "service_name", "interface", "region", "url"}
+	endpointMetrics := []Metric{
+		{Name: "endpoint_up", Help: "Whether the version document of a catalog endpoint answered without a server error", Labels: labels},
+		{Name: "endpoint_latency_seconds", Help: "Time taken to fetch the version document of a catalog endpoint", Labels: labels},
+		{Name: "endpoint_http_status", Help: "HTTP status code returned for the version document of a catalog endpoint", Labels: labels},
+	}
+
+	return &EndpointCollector{
+		metrics: newMetrics(cloud, endpointMetrics),
+		cloud:   cloud,
+		session: sessionFor(cloud),
+	}
+}
+
+func (ec *EndpointCollector) Describe(ch chan<- *prometheus.Desc) {
+	describeMetrics(ec.metrics, ch)
+}
+
+func (ec *EndpointCollector) Collect(ch chan<- prometheus.Metric) {
+	catalog, httpClient, errCatalog := ec.session.serviceCatalog()
+	if errCatalog != nil {
+		fmt.Printf("Error getting service catalog: %v\n", errCatalog)
+		return
+	}
+	httpClient.Timeout = endpointProbeTimeout
+
+	// Several interfaces of a service usually share one URL, so every
+	// version document is fetched only once per scrape.
+	var bases []string
+	seen := make(map[string]bool)
+	for _, entry := range catalog.Entries {
+		for _, endpoint := range entry.Endpoints {
+			base, errBase := utils.BaseEndpoint(endpoint.URL)
+			if errBase != nil {
+				base = endpoint.URL
+			}
+			if !seen[base] {
+				seen[base] = true
+				bases = append(bases, base)
+			}
+		}
+	}
+
+	// Every goroutine writes only its own index, the map is built once all
+	// of them are done.
+	results := make([]*endpointProbe, len(bases))
+	var wg sync.WaitGroup
+	for i, base := range bases {
+		wg.Add(1)
+		go func(i int, base string) {
+			defer wg.Done()
+			results[i] = probeEndpoint(&httpClient, base)
+		}(i, base)
+	}
+	wg.Wait()
+
+	probes := make(map[string]*endpointProbe, len(bases))
+	for i, base := range bases {
+		probes[base] = results[i]
+	}
+
+	for _, entry := range catalog.Entries {
+		for _, endpoint := range entry.Endpoints {
+			base, errBase := utils.BaseEndpoint(endpoint.URL)
+			if errBase != nil {
+				base = endpoint.URL
+			}
+			probe := probes[base]
+			if probe.err != nil {
+				fmt.Printf("Error probing %s endpoint %s: %v\n", entry.Type, base, probe.err)
+			}
+
+			labels := []string{entry.Type, entry.Name, endpoint.Interface, endpoint.Region, endpoint.URL}
+			ch <- prometheus.MustNewConstMetric(
+				ec.metrics["endpoint_up"].Metric,
+				prometheus.GaugeValue,
+				boolValue(probe.err == nil && probe.status < http.StatusInternalServerError),
+				labels...,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				ec.metrics["endpoint_latency_seconds"].Metric,
+				prometheus.GaugeValue,
+				probe.latency.Seconds(),
+				labels...,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				ec.metrics["endpoint_http_status"].Metric,
+				prometheus.GaugeValue,
+				float64(probe.status),
+				labels...,
+			)
+		}
+	}
+}
+
+func probeEndpoint(client *http.Client, url string) *endpointProbe {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return &endpointProbe{err: err}
+	}
+	req.Header.Set("Accept", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return &endpointProbe{latency: latency, err: err}
+	}
+	_ = resp.Body.Close()
+
+	return &endpointProbe{status: resp.StatusCode, latency: latency}
+}
@@ -0,0 +1,153 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/hypervisors"
+	"github.com/gophercloud/gophercloud/v2/openstack/placement/v1/resourceproviders"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type HypervisorCollector struct {
+	metrics map[string]Metric
+	cloud   Cloud
+	session *cloudSession
+}
+
+func NewHypervisorCollector(cloud Cloud) *HypervisorCollector {
+	hypervisorLabels := []string{"hypervisor", "type"}
+	providerLabels := []string{"resource_provider", "resource_class"}
+	hypervisorMetrics := []Metric{
+		{Name: "hypervisor_vcpus", Help: "Number of vCPUs of a hypervisor", Labels: hypervisorLabels},
+		{Name: "hypervisor_vcpus_used", Help: "Number of vCPUs used on a hypervisor", Labels: hypervisorLabels},
+		{Name: "hypervisor_memory_mb", Help: "Memory of a hypervisor in MB", Labels: hypervisorLabels},
+		{Name: "hypervisor_memory_mb_used", Help: "Memory used on a hypervisor in MB", Labels: hypervisorLabels},
+		{Name: "hypervisor_local_gb", Help: "Local disk of a hypervisor in GB", Labels: hypervisorLabels},
+		{Name: "hypervisor_local_gb_used", Help: "Local disk used on a hypervisor in GB", Labels: hypervisorLabels},
+		{Name: "hypervisor_running_vms", Help: "Number of instances running on a hypervisor", Labels: hypervisorLabels},
+		{Name: "hypervisor_up", Help: "Whether the state of a hypervisor is up", Labels: hypervisorLabels},
+		{Name: "hypervisor_enabled", Help: "Whether the status of a hypervisor is enabled", Labels: hypervisorLabels},
+		{Name: "resource_provider_inventory_total", Help: "Total amount of a resource class in a placement resource provider", Labels: providerLabels},
+		{Name: "resource_provider_inventory_reserved", Help: "Reserved amount of a resource class in a placement resource provider", Labels: providerLabels},
+		{Name: "resource_provider_allocation_ratio", Help: "Allocation ratio of a resource class in a placement resource provider", Labels: providerLabels},
+		{Name: "resource_provider_usage", Help: "Allocated amount of a resource class in a placement resource provider", Labels: providerLabels},
+	}
+
+	return &HypervisorCollector{
+		metrics: newMetrics(cloud, hypervisorMetrics),
+		cloud:   cloud,
+		session: sessionFor(cloud),
+	}
+}
+
+func (hc *HypervisorCollector) Describe(ch chan<- *prometheus.Desc) {
+	describeMetrics(hc.metrics, ch)
+}
+
+func (hc *HypervisorCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+
+	computeClient, errCompute := hc.session.serviceClient("compute", openstack.NewComputeV2)
+	if errCompute == nil {
+		hc.collectHypervisors(ctx, computeClient, ch)
+	}
+
+	placementClient, errPlacement := hc.session.serviceClient("placement", openstack.NewPlacementV1)
+	if errPlacement == nil {
+		hc.collectResourceProviders(ctx, placementClient, ch)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (hc *HypervisorCollector) collectHypervisors(ctx context.Context, client *gophercloud.ServiceClient, ch chan<- prometheus.Metric) {
+	allPages, errList := hypervisors.List(client, hypervisors.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing hypervisors: %v\n", errList)
+		hc.session.invalidate(errList)
+		return
+	}
+
+	allHypervisors, errExtract := hypervisors.ExtractHypervisors(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting hypervisors: %v\n", errExtract)
+		return
+	}
+
+	for _, h := range allHypervisors {
+		values := map[string]float64{
+			"hypervisor_vcpus":          float64(h.VCPUs),
+			"hypervisor_vcpus_used":     float64(h.VCPUsUsed),
+			"hypervisor_memory_mb":      float64(h.MemoryMB),
+			"hypervisor_memory_mb_used": float64(h.MemoryMBUsed),
+			"hypervisor_local_gb":       float64(h.LocalGB),
+			"hypervisor_local_gb_used":  float64(h.LocalGBUsed),
+			"hypervisor_running_vms":    float64(h.RunningVMs),
+			"hypervisor_up":             boolValue(h.State == "up"),
+			"hypervisor_enabled":        boolValue(h.Status == "enabled"),
+		}
+		for name, value := range values {
+			ch <- prometheus.MustNewConstMetric(
+				hc.metrics[name].Metric,
+				prometheus.GaugeValue,
+				value,
+				h.HypervisorHostname,
+				h.HypervisorType,
+			)
+		}
+	}
+}
+
+func (hc *HypervisorCollector) collectResourceProviders(ctx context.Context, client *gophercloud.ServiceClient, ch chan<- prometheus.Metric) {
+	allPages, errList := resourceproviders.List(client, resourceproviders.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing resource providers: %v\n", errList)
+		hc.session.invalidate(errList)
+		return
+	}
+
+	providers, errExtract := resourceproviders.ExtractResourceProviders(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting resource providers: %v\n", errExtract)
+		return
+	}
+
+	for _, rp := range providers {
+		inventories, errInventories := resourceproviders.GetInventories(ctx, client, rp.UUID).Extract()
+		if errInventories != nil {
+			fmt.Printf("Error getting inventories of resource provider %s: %v\n", rp.Name, errInventories)
+			continue
+		}
+		usages, errUsages := resourceproviders.GetUsages(ctx, client, rp.UUID).Extract()
+		if errUsages != nil {
+			fmt.Printf("Error getting usages of resource provider %s: %v\n", rp.Name, errUsages)
+			continue
+		}
+
+		for class, inventory := range inventories.Inventories {
+			values := map[string]float64{
+				"resource_provider_inventory_total":    float64(inventory.Total),
+				"resource_provider_inventory_reserved": float64(inventory.Reserved),
+				"resource_provider_allocation_ratio":   float64(inventory.AllocationRatio),
+				"resource_provider_usage":              float64(usages.Usages[class]),
+			}
+			for name, value := range values {
+				ch <- prometheus.MustNewConstMetric(
+					hc.metrics[name].Metric,
+					prometheus.GaugeValue,
+					value,
+					rp.Name,
+					class,
+				)
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/aggregates"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultInstanceInfoLimit caps instance_info series when the cloud does not
+// set InstanceInfoLimit.
+const defaultInstanceInfoLimit = 5000
+
+type InstanceCollector struct {
+	metrics map[string]Metric
+	cloud   Cloud
+	session *cloudSession
+}
+
+func NewInstanceCollector(cloud Cloud) *InstanceCollector {
+	instanceMetrics := []Metric{
+		{Name: "instances", Help: "Number of instances by project and status", Labels: []string{"project_id", "status"}},
+		{Name: "instances_by_flavor", Help: "Number of instances by flavor", Labels: []string{"flavor"}},
+		{Name: "instances_by_image", Help: "Number of instances by image, empty for instances booted from volume", Labels: []string{"image_id"}},
+		{Name: "instances_by_availability_zone", Help: "Number of instances by availability zone", Labels: []string{"availability_zone"}},
+		{Name: "instances_by_aggregate", Help: "Number of instances running on the hosts of a host aggregate", Labels: []string{"aggregate"}},
+		{Name: "instance_info", Help: "Instance metadata", Labels: []string{
+			"id", "name", "project_id", "status", "flavor", "image_id", "availability_zone", "host",
+		}},
+		{Name: "instance_info_dropped", Help: "Number of instances left out of instance_info by the instance_info_limit"},
+	}
+
+	return &InstanceCollector{
+		metrics: newMetrics(cloud, instanceMetrics),
+		cloud:   cloud,
+		session: sessionFor(cloud),
+	}
+}
+
+func (ic *InstanceCollector) Describe(ch chan<- *prometheus.Desc) {
+	describeMetrics(ic.metrics, ch)
+}
+
+func (ic *InstanceCollector) Collect(ch chan<- prometheus.Metric) {
+	client, errCompute := ic.session.serviceClient("compute", openstack.NewComputeV2)
+	if errCompute != nil {
+		return
+	}
+
+	ctx := context.Background()
+	allPages, errList := servers.List(client, servers.ListOpts{AllTenants: true}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing servers: %v\n", errList)
+		ic.session.invalidate(errList)
+		return
+	}
+
+	allServers, errExtract := servers.ExtractServers(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting servers: %v\n", errExtract)
+		return
+	}
+
+	flavorNames := ic.flavorNames(ctx, client)
+	hostAggregates := ic.hostAggregates(ctx, client)
+
+	type projectStatus struct {
+		projectID, status string
+	}
+	byProject := make(map[projectStatus]int)
+	byFlavor := make(map[string]int)
+	byImage := make(map[string]int)
+	byZone := make(map[string]int)
+	byAggregate := make(map[string]int)
+
+	limit := ic.cloud.InstanceInfoLimit
+	if limit <= 0 {
+		limit = defaultInstanceInfoLimit
+	}
+	dropped := 0
+
+	for i, s := range allServers {
+		flavor := serverFlavor(s, flavorNames)
+		image := serverImage(s)
+
+		byProject[projectStatus{s.TenantID, s.Status}]++
+		byFlavor[flavor]++
+		byImage[image]++
+		byZone[s.AvailabilityZone]++
+		for _, aggregate := range hostAggregates[s.Host] {
+			byAggregate[aggregate]++
+		}
+
+		if !ic.cloud.InstanceInfo {
+			continue
+		}
+		if i >= limit {
+			dropped++
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			ic.metrics["instance_info"].Metric,
+			prometheus.GaugeValue,
+			1.0,
+			s.ID, s.Name, s.TenantID, s.Status, flavor, image, s.AvailabilityZone, s.Host,
+		)
+	}
+
+	for key, count := range byProject {
+		ch <- prometheus.MustNewConstMetric(ic.metrics["instances"].Metric, prometheus.GaugeValue, float64(count), key.projectID, key.status)
+	}
+	counts := map[string]map[string]int{
+		"instances_by_flavor":            byFlavor,
+		"instances_by_image":             byImage,
+		"instances_by_availability_zone": byZone,
+		"instances_by_aggregate":         byAggregate,
+	}
+	for name, values := range counts {
+		for label, count := range values {
+			ch <- prometheus.MustNewConstMetric(ic.metrics[name].Metric, prometheus.GaugeValue, float64(count), label)
+		}
+	}
+	if ic.cloud.InstanceInfo {
+		ch <- prometheus.MustNewConstMetric(ic.metrics["instance_info_dropped"].Metric, prometheus.GaugeValue, float64(dropped))
+	}
+}
+
+// flavorNames maps flavor IDs to names for servers listed with a microversion
+// that only embeds the flavor ID.
+func (ic *InstanceCollector) flavorNames(ctx context.Context, client *gophercloud.ServiceClient) map[string]string {
+	names := make(map[string]string)
+
+	allPages, errList := flavors.ListDetail(client, flavors.ListOpts{AccessType: flavors.AllAccess}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing flavors: %v\n", errList)
+		return names
+	}
+	allFlavors, errExtract := flavors.ExtractFlavors(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting flavors: %v\n", errExtract)
+		return names
+	}
+
+	for _, f := range allFlavors {
+		names[f.ID] = f.Name
+	}
+	return names
+}
+
+// hostAggregates maps compute hosts to the names of their host aggregates.
+func (ic *InstanceCollector) hostAggregates(ctx context.Context, client *gophercloud.ServiceClient) map[string][]string {
+	hosts := make(map[string][]string)
+
+	allPages, errList := aggregates.List(client).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing aggregates: %v\n", errList)
+		return hosts
+	}
+	allAggregates, errExtract := aggregates.ExtractAggregates(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting aggregates: %v\n", errExtract)
+		return hosts
+	}
+
+	for _, a := range allAggregates {
+		for _, host := range a.Hosts {
+			hosts[host] = append(hosts[host], a.Name)
+		}
+	}
+	return hosts
+}
+
+func serverFlavor(s servers.Server, names map[string]string) string {
+	if name, ok := s.Flavor["original_name"].(string); ok {
+		return name
+	}
+	id, _ := s.Flavor["id"].(string)
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return id
+}
+
+func serverImage(s servers.Server) string {
+	id, _ := s.Image["id"].(string)
+	return id
+}
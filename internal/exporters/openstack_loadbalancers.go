@@ -0,0 +1,127 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/loadbalancer/v2/loadbalancers"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type LoadBalancerCollector struct {
+	metrics map[string]Metric
+	cloud   Cloud
+	session *cloudSession
+}
+
+func NewLoadBalancerCollector(cloud Cloud) *LoadBalancerCollector {
+	loadBalancerMetrics := []Metric{
+		{Name: "loadbalancer_status", Help: "Load balancer provisioning and operating status", Labels: []string{
+			"id", "name", "project_id", "provisioning_status", "operating_status",
+		}},
+		{Name: "loadbalancer_listener_status", Help: "Load balancer listener provisioning and operating status", Labels: []string{
+			"loadbalancer_id", "id", "name", "provisioning_status", "operating_status",
+		}},
+		{Name: "loadbalancer_pool_status", Help: "Load balancer pool provisioning and operating status", Labels: []string{
+			"loadbalancer_id", "id", "name", "provisioning_status", "operating_status",
+		}},
+		{Name: "loadbalancer_member_status", Help: "Load balancer pool member provisioning and operating status", Labels: []string{
+			"loadbalancer_id", "pool_id", "id", "name", "address", "provisioning_status", "operating_status",
+		}},
+		{Name: "loadbalancer_member_online", Help: "Whether a load balancer pool member is ONLINE", Labels: []string{
+			"loadbalancer_id", "pool_id", "id",
+		}},
+	}
+
+	return &LoadBalancerCollector{
+		metrics: newMetrics(cloud, loadBalancerMetrics),
+		cloud:   cloud,
+		session: sessionFor(cloud),
+	}
+}
+
+func (lc *LoadBalancerCollector) Describe(ch chan<- *prometheus.Desc) {
+	describeMetrics(lc.metrics, ch)
+}
+
+// Collect lists every load balancer and fetches its status tree, which costs
+// one request per load balancer.
+func (lc *LoadBalancerCollector) Collect(ch chan<- prometheus.Metric) {
+	client, errClient := lc.session.serviceClient("load balancer", openstack.NewLoadBalancerV2)
+	if errClient != nil {
+		return
+	}
+
+	ctx := context.Background()
+	allPages, errList := loadbalancers.List(client, loadbalancers.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		fmt.Printf("Error listing load balancers: %v\n", errList)
+		lc.session.invalidate(errList)
+		return
+	}
+
+	allLoadBalancers, errExtract := loadbalancers.ExtractLoadBalancers(allPages)
+	if errExtract != nil {
+		fmt.Printf("Error extracting load balancers: %v\n", errExtract)
+		return
+	}
+
+	for _, lb := range allLoadBalancers {
+		ch <- prometheus.MustNewConstMetric(
+			lc.metrics["loadbalancer_status"].Metric,
+			prometheus.GaugeValue,
+			1.0,
+			lb.ID, lb.Name, lb.ProjectID, lb.ProvisioningStatus, lb.OperatingStatus,
+		)
+
+		statuses, errStatuses := loadbalancers.GetStatuses(ctx, client, lb.ID).Extract()
+		if errStatuses != nil {
+			fmt.Printf("Error getting statuses of load balancer %s: %v\n", lb.ID, errStatuses)
+			continue
+		}
+		if statuses.Loadbalancer == nil {
+			continue
+		}
+
+		// A pool can be shared by several listeners of the same load
+		// balancer and shows up once under each of them.
+		seenPools := make(map[string]bool)
+		for _, listener := range statuses.Loadbalancer.Listeners {
+			ch <- prometheus.MustNewConstMetric(
+				lc.metrics["loadbalancer_listener_status"].Metric,
+				prometheus.GaugeValue,
+				1.0,
+				lb.ID, listener.ID, listener.Name, listener.ProvisioningStatus, listener.OperatingStatus,
+			)
+
+			for _, pool := range listener.Pools {
+				if seenPools[pool.ID] {
+					continue
+				}
+				seenPools[pool.ID] = true
+
+				ch <- prometheus.MustNewConstMetric(
+					lc.metrics["loadbalancer_pool_status"].Metric,
+					prometheus.GaugeValue,
+					1.0,
+					lb.ID, pool.ID, pool.Name, pool.ProvisioningStatus, pool.OperatingStatus,
+				)
+				for _, member := range pool.Members {
+					ch <- prometheus.MustNewConstMetric(
+						lc.metrics["loadbalancer_member_status"].Metric,
+						prometheus.GaugeValue,
+						1.0,
+						lb.ID, pool.ID, member.ID, member.Name, member.Address, member.ProvisioningStatus, member.OperatingStatus,
+					)
+					ch <- prometheus.MustNewConstMetric(
+						lc.metrics["loadbalancer_member_online"].Metric,
+						prometheus.GaugeValue,
+						boolValue(member.OperatingStatus == "ONLINE"),
+						lb.ID, pool.ID, member.ID,
+					)
+				}
+			}
+		}
+	}
+}
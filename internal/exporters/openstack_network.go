@@ -0,0 +1,107 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/routers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type NetworkCollector struct {
+	metrics map[string]Metric
+	cloud   Cloud
+	session *cloudSession
+}
+
+func NewNetworkCollector(cloud Cloud) *NetworkCollector {
+	networkMetrics := []Metric{
+		{Name: "routers", Help: "Number of routers by project and status", Labels: []string{"project_id", "status"}},
+		{Name: "floating_ips", Help: "Number of floating IPs by project, status and whether they are associated with a port", Labels: []string{"project_id", "status", "associated"}},
+		{Name: "ports", Help: "Number of ports by project and status", Labels: []string{"project_id", "status"}},
+	}
+
+	return &NetworkCollector{
+		metrics: newMetrics(cloud, networkMetrics),
+		cloud:   cloud,
+		session: sessionFor(cloud),
+	}
+}
+
+func (nc *NetworkCollector) Describe(ch chan<- *prometheus.Desc) {
+	describeMetrics(nc.metrics, ch)
+}
+
+type projectStatusKey struct {
+	projectID, status string
+}
+
+func (nc *NetworkCollector) Collect(ch chan<- prometheus.Metric) {
+	client, errNetwork := nc.session.serviceClient("network", openstack.NewNetworkV2)
+	if errNetwork != nil {
+		return
+	}
+
+	ctx := context.Background()
+
+	routerPages, errRouters := routers.List(client, routers.ListOpts{}).AllPages(ctx)
+	if errRouters != nil {
+		fmt.Printf("Error listing routers: %v\n", errRouters)
+		nc.session.invalidate(errRouters)
+	} else if allRouters, errExtract := routers.ExtractRouters(routerPages); errExtract != nil {
+		fmt.Printf("Error extracting routers: %v\n", errExtract)
+	} else {
+		byProject := make(map[projectStatusKey]int)
+		for _, r := range allRouters {
+			byProject[projectStatusKey{r.ProjectID, r.Status}]++
+		}
+		for key, count := range byProject {
+			ch <- prometheus.MustNewConstMetric(nc.metrics["routers"].Metric, prometheus.GaugeValue, float64(count), key.projectID, key.status)
+		}
+	}
+
+	floatingIPPages, errFloatingIPs := floatingips.List(client, floatingips.ListOpts{}).AllPages(ctx)
+	if errFloatingIPs != nil {
+		fmt.Printf("Error listing floating IPs: %v\n", errFloatingIPs)
+		nc.session.invalidate(errFloatingIPs)
+	} else if allFloatingIPs, errExtract := floatingips.ExtractFloatingIPs(floatingIPPages); errExtract != nil {
+		fmt.Printf("Error extracting floating IPs: %v\n", errExtract)
+	} else {
+		type floatingIPKey struct {
+			projectStatusKey
+			associated bool
+		}
+		byProject := make(map[floatingIPKey]int)
+		for _, ip := range allFloatingIPs {
+			byProject[floatingIPKey{projectStatusKey{ip.ProjectID, ip.Status}, ip.PortID != ""}]++
+		}
+		for key, count := range byProject {
+			ch <- prometheus.MustNewConstMetric(
+				nc.metrics["floating_ips"].Metric,
+				prometheus.GaugeValue,
+				float64(count),
+				key.projectID, key.status, strconv.FormatBool(key.associated),
+			)
+		}
+	}
+
+	portPages, errPorts := ports.List(client, ports.ListOpts{}).AllPages(ctx)
+	if errPorts != nil {
+		fmt.Printf("Error listing ports: %v\n", errPorts)
+		nc.session.invalidate(errPorts)
+	} else if allPorts, errExtract := ports.ExtractPorts(portPages); errExtract != nil {
+		fmt.Printf("Error extracting ports: %v\n", errExtract)
+	} else {
+		byProject := make(map[projectStatusKey]int)
+		for _, p := range allPorts {
+			byProject[projectStatusKey{p.ProjectID, p.Status}]++
+		}
+		for key, count := range byProject {
+			ch <- prometheus.MustNewConstMetric(nc.metrics["ports"].Metric, prometheus.GaugeValue, float64(count), key.projectID, key.status)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package exporters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	cinderquotas "github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/quotasets"
+	novaquotas "github.com/gophercloud/gophercloud/v2/openstack/compute/v2/quotasets"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	neutronquotas "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/quotas"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type QuotaCollector struct {
+	metrics map[string]Metric
+	cloud   Cloud
+	session *cloudSession
+}
+
+type quotaUsage struct {
+	service  string
+	resource string
+	limit    int
+	used     int
+}
+
+func NewQuotaCollector(cloud Cloud) *QuotaCollector {
+	labels := []string{"project_id", "project_name", "service", "resource"}
+	quotaMetrics := []Metric{
+		{Name: "quota_limit", Help: "Quota limit of a project, -1 means unlimited", Labels: labels},
+		{Name: "quota_used", Help: "Quota usage of a project", Labels: labels},
+	}
+
+	return &QuotaCollector{
+		metrics: newMetrics(cloud, quotaMetrics),
+		cloud:   cloud,
+		session: sessionFor(cloud),
+	}
+}
+
+func (qc *QuotaCollector) Describe(ch chan<- *prometheus.Desc) {
+	describeMetrics(qc.metrics, ch)
+}
+
+// listProjects returns every project Keystone knows about.
+func listProjects(ctx context.Context, client *gophercloud.ServiceClient) ([]projects.Project, error) {
+	allPages, errList := projects.List(client, projects.ListOpts{}).AllPages(ctx)
+	if errList != nil {
+		return nil, errList
+	}
+	return projects.ExtractProjects(allPages)
+}
+
+func (qc *QuotaCollector) Collect(ch chan<- prometheus.Metric) {
+	identityClient, errIdentity := qc.session.identityClient()
+	if errIdentity != nil {
+		fmt.Printf("Authentication error: %v\n", errIdentity)
+		return
+	}
+	computeClient, errCompute := qc.session.serviceClient("compute", openstack.NewComputeV2)
+	if errCompute != nil {
+		return
+	}
+	volumeClient, errVolume := qc.session.serviceClient("block storage", openstack.NewBlockStorageV3)
+	if errVolume != nil {
+		return
+	}
+	networkClient, errNetwork := qc.session.serviceClient("network", openstack.NewNetworkV2)
+	if errNetwork != nil {
+		return
+	}
+
+	ctx := context.Background()
+	allProjects, errList := listProjects(ctx, identityClient)
+	if errList != nil {
+		fmt.Printf("Error listing projects: %v\n", errList)
+		qc.session.invalidate(errList)
+		return
+	}
+
+	for _, p := range allProjects {
+		var usages []quotaUsage
+
+		compute, errComputeQuota := novaquotas.GetDetail(ctx, computeClient, p.ID).Extract()
+		if errComputeQuota != nil {
+			fmt.Printf("Error getting compute quota of %s: %v\n", p.Name, errComputeQuota)
+		} else {
+			usages = append(usages,
+				quotaUsage{"compute", "cores", compute.Cores.Limit, compute.Cores.InUse},
+				quotaUsage{"compute", "ram_mb", compute.RAM.Limit, compute.RAM.InUse},
+				quotaUsage{"compute", "instances", compute.Instances.Limit, compute.Instances.InUse},
+			)
+		}
+
+		volume, errVolumeQuota := cinderquotas.GetUsage(ctx, volumeClient, p.ID).Extract()
+		if errVolumeQuota != nil {
+			fmt.Printf("Error getting block storage quota of %s: %v\n", p.Name, errVolumeQuota)
+		} else {
+			usages = append(usages,
+				quotaUsage{"volume", "volumes", volume.Volumes.Limit, volume.Volumes.InUse},
+				quotaUsage{"volume", "gigabytes", volume.Gigabytes.Limit, volume.Gigabytes.InUse},
+			)
+		}
+
+		network, errNetworkQuota := neutronquotas.GetDetail(ctx, networkClient, p.ID).Extract()
+		if errNetworkQuota != nil {
+			fmt.Printf("Error getting network quota of %s: %v\n", p.Name, errNetworkQuota)
+		} else {
+			usages = append(usages,
+				quotaUsage{"network", "floating_ips", network.FloatingIP.Limit, network.FloatingIP.Used},
+				quotaUsage{"network", "security_groups", network.SecurityGroup.Limit, network.SecurityGroup.Used},
+			)
+		}
+
+		for _, u := range usages {
+			ch <- prometheus.MustNewConstMetric(
+				qc.metrics["quota_limit"].Metric,
+				prometheus.GaugeValue,
+				float64(u.limit),
+				p.ID, p.Name, u.service, u.resource,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				qc.metrics["quota_used"].Metric,
+				prometheus.GaugeValue,
+				float64(u.used),
+				p.ID, p.Name, u.service, u.resource,
+			)
+		}
+	}
+}